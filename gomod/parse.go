@@ -0,0 +1,79 @@
+package gomod
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+// ParseGoMod returns the requirements listed in go.mod content.
+func ParseGoMod(data []byte) ([]*Requirement, error) {
+	var reqs []*Requirement
+	var inBlock bool
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i != -1 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if inBlock {
+			if fields[0] == ")" {
+				inBlock = false
+				continue
+			}
+		} else {
+			if fields[0] != "require" {
+				if len(fields) > 1 && fields[1] == "(" {
+					// Skip over the contents of other blocks
+					for scanner.Scan() {
+						lineNum++
+						if strings.TrimSpace(scanner.Text()) == ")" {
+							break
+						}
+					}
+				}
+				continue
+			}
+			fields = fields[1:]
+			if len(fields) == 1 && fields[0] == "(" {
+				inBlock = true
+				continue
+			}
+		}
+		if len(fields) != 2 {
+			return nil, errs.New(fmt.Sprintf("go.mod:%d: malformed requirement", lineNum))
+		}
+		reqs = append(reqs, &Requirement{Module: unquote(fields[0]), Version: unquote(fields[1])})
+	}
+	return reqs, errs.Wrap(scanner.Err())
+}
+
+// ParseGoSum returns the module versions whose content is recorded in go.sum
+// content. Entries that only record the hash of a go.mod file are skipped.
+func ParseGoSum(data []byte) []*Requirement {
+	var reqs []*Requirement
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) == 3 && !strings.HasSuffix(fields[1], "/go.mod") {
+			reqs = append(reqs, &Requirement{Module: fields[0], Version: fields[1]})
+		}
+	}
+	return reqs
+}
+
+func unquote(text string) string {
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		return unquoted
+	}
+	return text
+}
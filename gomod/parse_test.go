@@ -0,0 +1,69 @@
+package gomod
+
+import "testing"
+
+func TestParseGoMod(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		data    string
+		want    []Requirement
+		wantErr bool
+	}{
+		{
+			name: "single and block requirements",
+			data: `module github.com/user/project
+
+go 1.13
+
+require github.com/user/a v1.0.0
+
+require (
+	github.com/user/b v0.0.0-20191109021931-daa7c04131f5 // indirect
+	"github.com/user/c/v2" v2.1.0
+)
+
+replace (
+	github.com/user/d v1.0.0 => ../d
+)
+
+exclude github.com/user/e v1.0.0
+`,
+			want: []Requirement{
+				{Module: "github.com/user/a", Version: "v1.0.0"},
+				{Module: "github.com/user/b", Version: "v0.0.0-20191109021931-daa7c04131f5"},
+				{Module: "github.com/user/c/v2", Version: "v2.1.0"},
+			},
+		},
+		{name: "none", data: "module github.com/user/project\n"},
+		{name: "malformed", data: "require github.com/user/a\n", wantErr: true},
+	} {
+		got, err := ParseGoMod([]byte(test.data))
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: unexpected error state: %v", test.name, err)
+			continue
+		}
+		checkRequirements(t, test.name, got, test.want)
+	}
+}
+
+func TestParseGoSum(t *testing.T) {
+	data := `github.com/user/a v1.0.0 h1:abc=
+github.com/user/a v1.0.0/go.mod h1:def=
+github.com/user/b v0.1.0/go.mod h1:ghi=
+malformed line
+`
+	checkRequirements(t, "go.sum", ParseGoSum([]byte(data)), []Requirement{{Module: "github.com/user/a", Version: "v1.0.0"}})
+}
+
+func checkRequirements(t *testing.T, name string, got []*Requirement, want []Requirement) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s: got %d requirements, want %d", name, len(got), len(want))
+		return
+	}
+	for i := range want {
+		if *got[i] != want[i] {
+			t.Errorf("%s: requirement %d: got %+v, want %+v", name, i, *got[i], want[i])
+		}
+	}
+}
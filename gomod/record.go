@@ -0,0 +1,105 @@
+package gomod
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/richardwilkes/gopathdep/repo"
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+// Dependencies reads go.mod, and go.sum if present, from the directory and
+// returns the equivalent dependencies. Modules are grouped by the repo roots
+// provided, with release versions becoming tags and pseudo-versions becoming
+// commits. Commits that could not be expanded to their full hash, because the
+// repo isn't present in $GOPATH, are also returned.
+func Dependencies(dir string, roots []string) (deps repo.Dependencies, abbreviated []string, err error) {
+	var data []byte
+	if data, err = ioutil.ReadFile(filepath.Join(dir, "go.mod")); err != nil {
+		return nil, nil, errs.NewWithCause(fmt.Sprintf("Unable to read %s", filepath.Join(dir, "go.mod")), err)
+	}
+	var reqs []*Requirement
+	if reqs, err = ParseGoMod(data); err != nil {
+		return nil, nil, err
+	}
+	var sums []*Requirement
+	if data, err = ioutil.ReadFile(filepath.Join(dir, "go.sum")); err == nil {
+		sums = ParseGoSum(data)
+	} else if !os.IsNotExist(err) {
+		return nil, nil, errs.Wrap(err)
+	}
+	chosen := make(map[string]*Requirement)
+	for _, req := range reqs {
+		importPath := importPathForModule(req.Module, roots)
+		if existing, exists := chosen[importPath]; !exists || isNewer(req.Version, existing.Version) {
+			chosen[importPath] = req
+		}
+	}
+	for _, sum := range sums {
+		importPath := importPathForModule(sum.Module, roots)
+		if !containsRoot(roots, importPath) {
+			continue
+		}
+		if existing, exists := chosen[importPath]; !exists || (!isRequired(reqs, existing) && isNewer(sum.Version, existing.Version)) {
+			chosen[importPath] = sum
+		}
+	}
+	deps = make(repo.Dependencies, 0, len(chosen))
+	for importPath, req := range chosen {
+		dep := &repo.Dependency{Import: importPath}
+		if repo.IsPseudoVersion(req.Version) {
+			dep.Commit = repo.PseudoVersionRevision(req.Version)
+			if r, rErr := repo.NewFromImportPath(importPath, false); rErr == nil && r.System() == repo.Git {
+				if full, revErr := r.Exec("rev-parse", "--verify", dep.Commit+"^{commit}"); revErr == nil {
+					dep.Commit = full
+				}
+			}
+			if len(dep.Commit) < 40 {
+				abbreviated = append(abbreviated, importPath)
+			}
+		} else {
+			dep.Tag = strings.TrimSuffix(req.Version, "+incompatible")
+		}
+		deps = append(deps, dep)
+	}
+	return deps, abbreviated, nil
+}
+
+// importPathForModule returns the repo root import path that the module
+// belongs to, preferring one of the roots found by scanning the imports.
+func importPathForModule(module string, roots []string) string {
+	stripped := StripPathMajor(module)
+	for _, root := range roots {
+		if root == module || root == stripped || strings.HasPrefix(module, root+"/") {
+			return root
+		}
+	}
+	return repo.RootImportPath(stripped)
+}
+
+func containsRoot(roots []string, importPath string) bool {
+	for _, root := range roots {
+		if root == importPath || strings.HasPrefix(root, importPath+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func isRequired(reqs []*Requirement, req *Requirement) bool {
+	for _, one := range reqs {
+		if one == req {
+			return true
+		}
+	}
+	return false
+}
+
+func isNewer(version, than string) bool {
+	v1, ok1 := repo.ParseSemVer(strings.TrimSuffix(version, "+incompatible"))
+	v2, ok2 := repo.ParseSemVer(strings.TrimSuffix(than, "+incompatible"))
+	return ok1 && (!ok2 || v1.Compare(v2) > 0)
+}
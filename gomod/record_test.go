@@ -0,0 +1,38 @@
+package gomod
+
+import "testing"
+
+func TestImportPathForModule(t *testing.T) {
+	roots := []string{"github.com/user/a", "example.com/vanity/b"}
+	for _, test := range []struct {
+		module string
+		want   string
+	}{
+		{module: "github.com/user/a", want: "github.com/user/a"},
+		{module: "github.com/user/a/v3", want: "github.com/user/a"},
+		{module: "example.com/vanity/b/sub", want: "example.com/vanity/b"},
+	} {
+		if got := importPathForModule(test.module, roots); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.module, got, test.want)
+		}
+	}
+}
+
+func TestIsNewer(t *testing.T) {
+	for _, test := range []struct {
+		version string
+		than    string
+		want    bool
+	}{
+		{version: "v1.2.0", than: "v1.1.9", want: true},
+		{version: "v1.1.9", than: "v1.2.0"},
+		{version: "v1.2.0", than: "v1.2.0"},
+		{version: "v3.0.0+incompatible", than: "v2.1.0+incompatible", want: true},
+		{version: "v1.0.0", than: "bogus", want: true},
+		{version: "bogus", than: "v1.0.0"},
+	} {
+		if got := isNewer(test.version, test.than); got != test.want {
+			t.Errorf("%s > %s: got %v, want %v", test.version, test.than, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package gomod
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/richardwilkes/gopathdep/repo"
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+// Requirement holds a module requirement.
+type Requirement struct {
+	Module  string
+	Version string
+}
+
+// NewRequirement determines the module requirement for a dependency, using
+// its clone in $GOPATH. Tags that are canonical semantic versions are used
+// as-is, while anything else is translated into a pseudo-version based on the
+// commit time.
+func NewRequirement(dep *repo.Dependency, lock *repo.Lock) (*Requirement, error) {
+	r, err := repo.NewFromImportPath(dep.Import, false)
+	if err != nil {
+		return nil, err
+	}
+	if r.System() != repo.Git {
+		return nil, errs.New(fmt.Sprintf("unable to determine a module version for %s, as it is not a git repo", dep.Import))
+	}
+	pinned := lock.Resolve(dep)
+	var tag, rev string
+	switch {
+	case pinned.Commit != "":
+		rev = pinned.Commit
+	case pinned.Tag != "":
+		tag = pinned.Tag
+		rev = tagRef(tag)
+	case pinned.Version != "":
+		var tags []string
+		if tags, err = r.Tags(); err == nil {
+			tag, err = pinned.ResolveVersion(tags)
+		}
+		if err != nil {
+			return nil, err
+		}
+		rev = tagRef(tag)
+	case pinned.Branch != "":
+		rev = "refs/remotes/origin/" + pinned.Branch
+	default:
+		var branch string
+		if branch, err = r.DefaultBranch(); err != nil {
+			return nil, err
+		}
+		rev = "refs/remotes/origin/" + branch
+	}
+	var commit string
+	if commit, err = r.Exec("rev-parse", "--verify", rev+"^{commit}"); err != nil {
+		return nil, errs.NewWithCause(fmt.Sprintf("unable to resolve %s in %s", rev, dep.Import), err)
+	}
+	req := &Requirement{Module: dep.Import}
+	goMod, hasGoMod := readFile(r, commit, "go.mod")
+	if hasGoMod {
+		if module := ModulePath(goMod); module != "" {
+			req.Module = module
+		}
+	}
+	pathMajor := PathMajor(req.Module)
+	compatible := func(version string) (string, bool) {
+		v, ok := repo.ParseSemVer(version)
+		switch {
+		case !ok:
+			return "", false
+		case pathMajor != 0:
+			return version, v.Major == pathMajor
+		case v.Major < 2:
+			return version, true
+		default:
+			return version + "+incompatible", !hasGoMod
+		}
+	}
+
+	// Prefer a canonical tag that points at the commit
+	candidates := []string{tag}
+	if tag == "" {
+		var result string
+		if result, err = r.Exec("tag", "--points-at", commit); err == nil {
+			candidates = strings.Split(result, "\n")
+		}
+	}
+	for _, one := range candidates {
+		if one = strings.TrimSpace(one); IsCanonical(one) {
+			if version, ok := compatible(one); ok {
+				req.Version = version
+				return req, nil
+			}
+			if one == tag {
+				return nil, errs.New(fmt.Sprintf("tag %s of %s is not compatible with module path %s", tag, dep.Import, req.Module))
+			}
+		}
+	}
+
+	// Fall back to a pseudo-version based on the highest preceding tag
+	var base string
+	var baseVersion repo.SemVer
+	var result string
+	if result, err = r.Exec("tag", "--merged", commit); err == nil {
+		for _, one := range strings.Split(result, "\n") {
+			if one = strings.TrimSpace(one); IsCanonical(one) {
+				if version, ok := compatible(one); ok {
+					v, _ := repo.ParseSemVer(one)
+					if base == "" || v.Compare(baseVersion) > 0 {
+						base = version
+						baseVersion = v
+					}
+				}
+			}
+		}
+	}
+	if result, err = r.Exec("log", "-1", "--format=%ct", commit); err != nil {
+		return nil, err
+	}
+	var seconds int64
+	if seconds, err = strconv.ParseInt(result, 10, 64); err != nil {
+		return nil, errs.NewWithCause(fmt.Sprintf("unable to determine the commit time of %s in %s", commit, dep.Import), err)
+	}
+	req.Version = PseudoVersion(pathMajor, base, time.Unix(seconds, 0), commit)
+	return req, nil
+}
+
+// tagRef returns the full ref name for a tag.
+func tagRef(tag string) string {
+	return repo.TagPrefix + tag
+}
+
+// ModulePath returns the module path declared in go.mod content.
+func ModulePath(goMod string) string {
+	scanner := bufio.NewScanner(strings.NewReader(goMod))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "module" {
+			if path, err := strconv.Unquote(fields[1]); err == nil {
+				return path
+			}
+			return fields[1]
+		}
+	}
+	return ""
+}
+
+func readFile(r *repo.Repo, commit, path string) (string, bool) {
+	content, err := r.Exec("show", commit+":"+path)
+	return content, err == nil
+}
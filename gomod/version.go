@@ -0,0 +1,76 @@
+// Package gomod translates between dependencies and Go module requirements.
+package gomod
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/richardwilkes/gopathdep/repo"
+)
+
+const pseudoTimeFormat = "20060102150405"
+
+var (
+	canonicalRegex = regexp.MustCompile(`^v[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z.-]+)?$`)
+	gopkgInRegex   = regexp.MustCompile(`^gopkg\.in/(?:[^/]+/)?[^/]+\.v([0-9]+)(?:-unstable)?$`)
+	pathMajorRegex = regexp.MustCompile(`/v([0-9]+)$`)
+)
+
+// IsCanonical returns true if the version is a canonical semantic version of
+// the form required by Go modules, such as v1.2.3 or v1.2.3-beta.1.
+func IsCanonical(version string) bool {
+	return canonicalRegex.MatchString(version)
+}
+
+// PathMajor returns the major version implied by a module path, either from
+// a "/vN" suffix or a gopkg.in ".vN" suffix. Zero is returned if the path
+// doesn't imply one.
+func PathMajor(modulePath string) int {
+	m := gopkgInRegex.FindStringSubmatch(modulePath)
+	if m == nil {
+		if m = pathMajorRegex.FindStringSubmatch(modulePath); m == nil {
+			return 0
+		}
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// StripPathMajor removes a "/vN" major version suffix from a module path.
+func StripPathMajor(modulePath string) string {
+	if strings.HasPrefix(modulePath, "gopkg.in/") {
+		return modulePath
+	}
+	return pathMajorRegex.ReplaceAllString(modulePath, "")
+}
+
+// PseudoVersion returns the pseudo-version for a commit. The base is the
+// highest tag that precedes the commit, or an empty string if there isn't
+// one, in which case major is used.
+func PseudoVersion(major int, base string, t time.Time, commit string) string {
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	stamp := t.UTC().Format(pseudoTimeFormat)
+	if base == "" {
+		return fmt.Sprintf("v%d.0.0-%s-%s", major, stamp, commit)
+	}
+	incompatible := strings.HasSuffix(base, "+incompatible")
+	base = strings.TrimSuffix(base, "+incompatible")
+	var version string
+	if v, ok := repo.ParseSemVer(base); ok && v.PreRelease == "" {
+		version = fmt.Sprintf("v%d.%d.%d-0.%s-%s", v.Major, v.Minor, v.Patch+1, stamp, commit)
+	} else {
+		version = fmt.Sprintf("%s.0.%s-%s", base, stamp, commit)
+	}
+	if incompatible {
+		version += "+incompatible"
+	}
+	return version
+}
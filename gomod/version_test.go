@@ -0,0 +1,81 @@
+package gomod
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsCanonical(t *testing.T) {
+	for _, test := range []struct {
+		version string
+		want    bool
+	}{
+		{version: "v1.2.3", want: true},
+		{version: "v0.0.1-beta.1", want: true},
+		{version: "v1.2", want: false},
+		{version: "1.2.3", want: false},
+		{version: "v1.2.3+build", want: false},
+		{version: "release-1", want: false},
+	} {
+		if got := IsCanonical(test.version); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.version, got, test.want)
+		}
+	}
+}
+
+func TestPathMajor(t *testing.T) {
+	for _, test := range []struct {
+		path     string
+		major    int
+		stripped string
+	}{
+		{path: "github.com/user/project", stripped: "github.com/user/project"},
+		{path: "github.com/user/project/v2", major: 2, stripped: "github.com/user/project"},
+		{path: "github.com/user/project/v10", major: 10, stripped: "github.com/user/project"},
+		{path: "gopkg.in/yaml.v3", major: 3, stripped: "gopkg.in/yaml.v3"},
+		{path: "gopkg.in/user/pkg.v1-unstable", major: 1, stripped: "gopkg.in/user/pkg.v1-unstable"},
+		{path: "github.com/user/v2project", stripped: "github.com/user/v2project"},
+	} {
+		if got := PathMajor(test.path); got != test.major {
+			t.Errorf("PathMajor(%s): got %d, want %d", test.path, got, test.major)
+		}
+		if got := StripPathMajor(test.path); got != test.stripped {
+			t.Errorf("StripPathMajor(%s): got %s, want %s", test.path, got, test.stripped)
+		}
+	}
+}
+
+func TestPseudoVersion(t *testing.T) {
+	stamp := time.Date(2019, 11, 9, 2, 19, 31, 0, time.UTC)
+	commit := "daa7c04131f5b1f6e1a2b4c3d5e6f708192a3b4c"
+	for _, test := range []struct {
+		major int
+		base  string
+		want  string
+	}{
+		{want: "v0.0.0-20191109021931-daa7c04131f5"},
+		{major: 2, want: "v2.0.0-20191109021931-daa7c04131f5"},
+		{base: "v1.2.3", want: "v1.2.4-0.20191109021931-daa7c04131f5"},
+		{base: "v1.2.3-pre", want: "v1.2.3-pre.0.20191109021931-daa7c04131f5"},
+		{base: "v2.0.0+incompatible", want: "v2.0.1-0.20191109021931-daa7c04131f5+incompatible"},
+	} {
+		if got := PseudoVersion(test.major, test.base, stamp, commit); got != test.want {
+			t.Errorf("%d %q: got %s, want %s", test.major, test.base, got, test.want)
+		}
+	}
+}
+
+func TestModulePath(t *testing.T) {
+	for _, test := range []struct {
+		goMod string
+		want  string
+	}{
+		{goMod: "module github.com/user/project\n\ngo 1.13\n", want: "github.com/user/project"},
+		{goMod: "// comment\nmodule \"github.com/user/project/v2\"\n", want: "github.com/user/project/v2"},
+		{goMod: "go 1.13\n"},
+	} {
+		if got := ModulePath(test.goMod); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.goMod, got, test.want)
+		}
+	}
+}
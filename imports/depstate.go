@@ -18,6 +18,10 @@ const (
 	NotNeeded
 	IncorrectVersion
 	Dirty
+	WrongRemote
+	Unpushed
+	Diverged
+	InvalidConfig
 	Good
 )
 
@@ -26,6 +30,7 @@ type DepInfo struct {
 	Import     string
 	Dependency *repo.Dependency
 	State      DepState
+	Commit     string
 }
 
 // DepInfos holds multiple DepInfo records and provides convenient sorting.
@@ -46,6 +51,14 @@ func (ds DepState) MarkerAndDescription() (rune, string) {
 		return 'S', "needs to be synced with this configuration"
 	case Dirty:
 		return 'M', "is modified"
+	case WrongRemote:
+		return 'R', "has an origin that does not match the configured remote"
+	case Unpushed:
+		return 'U', "has local commits that are not on its remote"
+	case Diverged:
+		return 'V', "has local commits and has diverged from its remote"
+	case InvalidConfig:
+		return 'I', "has a version constraint that can't be parsed"
 	case Good:
 		return '✓', ""
 	default:
@@ -71,10 +84,12 @@ func GetDepInfo(cfg *repo.Config) DepInfos {
 	states := GetRepoStates(cfg.Dir)
 	pkgToStateMap := make(map[string]*repo.State)
 	for _, state := range states {
-		pkgToStateMap[state.Import] = state
+		if !cfg.IsIgnored(state.Import) {
+			pkgToStateMap[state.Import] = state
+		}
 	}
 	cfgCnt := len(cfg.Dependencies)
-	pkgCnt := len(states)
+	pkgCnt := len(pkgToStateMap)
 	if pkgCnt < cfgCnt {
 		pkgCnt = cfgCnt
 	}
@@ -84,7 +99,17 @@ func GetDepInfo(cfg *repo.Config) DepInfos {
 		if state, exists := pkgToStateMap[dep.Import]; exists {
 			delete(pkgToStateMap, dep.Import)
 			if state.Exists {
-				di.State = GetDepState(dep, state)
+				di.Commit = state.Commit
+				di.State = GetDepState(cfg.Lock.Resolve(dep), state)
+				if di.State == Good && state.Hash != "" {
+					// Without history, modifications can only be detected by
+					// the checksum recorded when the files were downloaded.
+					if locked := cfg.Lock.Find(dep); locked != nil && locked.Hash != "" && locked.Hash != state.Hash {
+						di.State = Dirty
+					}
+				}
+			} else if _, err := dep.Constraint(); err != nil {
+				di.State = InvalidConfig
 			} else {
 				di.State = MissingOnDisk
 			}
@@ -106,7 +131,19 @@ func GetDepInfo(cfg *repo.Config) DepInfos {
 
 // GetDepState returns the dependency status for a dependency.
 func GetDepState(dep *repo.Dependency, state *repo.State) DepState {
-	if (dep.Commit != "" && dep.Commit != state.Commit) || (dep.Tag != "" && !state.HasTag(dep.Tag) || (dep.Branch != "" && !state.HasBranch(dep.Branch))) {
+	constraint, err := dep.Constraint()
+	if err != nil {
+		return InvalidConfig
+	}
+	if dep.Remote != "" && !repo.SameRemote(dep.Remote, state.Origin) {
+		return WrongRemote
+	} else if state.Ahead > 0 && state.Behind > 0 {
+		return Diverged
+	} else if state.Ahead > 0 {
+		return Unpushed
+	} else if (dep.Commit != "" && dep.Commit != state.Commit) || (dep.Tag != "" && !state.HasTag(dep.Tag) || (dep.Branch != "" && !state.HasBranch(dep.Branch))) {
+		return IncorrectVersion
+	} else if constraint != nil && !state.HasMatchingTag(constraint) {
 		return IncorrectVersion
 	} else if state.Dirty {
 		return Dirty
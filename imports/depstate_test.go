@@ -0,0 +1,48 @@
+package imports
+
+import (
+	"testing"
+
+	"github.com/richardwilkes/gopathdep/repo"
+)
+
+func TestGetDepState(t *testing.T) {
+	const commit = "0123456789abcdef0123456789abcdef01234567"
+	for i, test := range []struct {
+		dep   repo.Dependency
+		state repo.State
+		want  DepState
+	}{
+		{dep: repo.Dependency{Commit: commit}, state: repo.State{Commit: commit}, want: Good},
+		{dep: repo.Dependency{Commit: commit}, state: repo.State{Commit: "fedcba"}, want: IncorrectVersion},
+		{dep: repo.Dependency{Commit: commit}, state: repo.State{Commit: commit, Dirty: true}, want: Dirty},
+		{dep: repo.Dependency{Tag: "v1.0.0"}, state: repo.State{Tags: []string{"v1.0.0"}}, want: Good},
+		{dep: repo.Dependency{Tag: "v1.0.0"}, state: repo.State{Tags: []string{"v1.1.0"}}, want: IncorrectVersion},
+		{dep: repo.Dependency{Branch: "main"}, state: repo.State{Branches: []string{"main"}}, want: Good},
+		{dep: repo.Dependency{Branch: "main"}, state: repo.State{Branches: []string{"main"}, Ahead: 1}, want: Unpushed},
+		{dep: repo.Dependency{Branch: "main"}, state: repo.State{Branches: []string{"main"}, Ahead: 1, Behind: 2}, want: Diverged},
+		{dep: repo.Dependency{Version: "^1.2"}, state: repo.State{Tags: []string{"v1.3.0"}}, want: Good},
+		{dep: repo.Dependency{Version: "^1.2"}, state: repo.State{Tags: []string{"v2.0.0"}}, want: IncorrectVersion},
+		{dep: repo.Dependency{Version: "^one"}, state: repo.State{Tags: []string{"v1.0.0"}}, want: InvalidConfig},
+		{dep: repo.Dependency{Version: ">=1.0 ||"}, state: repo.State{Dirty: true}, want: InvalidConfig},
+		{dep: repo.Dependency{Commit: commit, Remote: "https://example.com/a.git"}, state: repo.State{Commit: commit, Origin: "https://example.com/b.git"}, want: WrongRemote},
+		{dep: repo.Dependency{Commit: commit, Remote: "https://example.com/a.git"}, state: repo.State{Commit: commit, Origin: "https://example.com/a.git"}, want: Good},
+		{dep: repo.Dependency{Commit: commit, Remote: "https://example.com/a.git"}, state: repo.State{Commit: commit}, want: WrongRemote},
+		{dep: repo.Dependency{Commit: commit}, state: repo.State{Commit: commit}, want: Good},
+	} {
+		if got := GetDepState(&test.dep, &test.state); got != test.want {
+			t.Errorf("%d: got %v, want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestMarkerAndDescription(t *testing.T) {
+	seen := make(map[rune]DepState)
+	for ds := MissingConfig; ds <= Good; ds++ {
+		marker, _ := ds.MarkerAndDescription()
+		if other, exists := seen[marker]; exists {
+			t.Errorf("states %v and %v share the marker %c", other, ds, marker)
+		}
+		seen[marker] = ds
+	}
+}
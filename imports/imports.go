@@ -117,7 +117,7 @@ func collectPackageNamesFromSlice(pkgNames []string, srcDir string, set map[stri
 func findRepoRoot(root, path string) string {
 	dir := path
 	for {
-		if fi, err := os.Stat(filepath.Join(root, dir, ".git")); err == nil && fi.IsDir() {
+		if util.HasVCSDir(filepath.Join(root, dir)) {
 			return dir
 		}
 		dir = filepath.Dir(dir)
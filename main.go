@@ -4,10 +4,18 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/richardwilkes/gopathdep/repo"
 	"github.com/richardwilkes/gopathdep/subcmds/apply"
+	"github.com/richardwilkes/gopathdep/subcmds/cache"
 	"github.com/richardwilkes/gopathdep/subcmds/check"
+	"github.com/richardwilkes/gopathdep/subcmds/export"
+	"github.com/richardwilkes/gopathdep/subcmds/importer"
+	"github.com/richardwilkes/gopathdep/subcmds/lint"
+	"github.com/richardwilkes/gopathdep/subcmds/migrate"
 	"github.com/richardwilkes/gopathdep/subcmds/record"
 	"github.com/richardwilkes/gopathdep/subcmds/reset"
+	"github.com/richardwilkes/gopathdep/subcmds/unstash"
+	"github.com/richardwilkes/gopathdep/subcmds/update"
 	"github.com/richardwilkes/toolbox/cmdline"
 )
 
@@ -19,10 +27,19 @@ func main() {
 	cl := cmdline.New(true)
 	cl.Description = "Manage $GOPATH dependencies."
 	cl.UsageSuffix = "[path to repo]"
+	cl.NewBoolOption(&repo.Offline).SetName("offline").SetUsage("Never access the network. Comparisons are made against the remote state recorded by the last fetch, which may be stale")
+	cl.NewBoolOption(&repo.UseCache).SetName("use-cache").SetUsage(fmt.Sprintf("Clone and fetch git repos by way of a shared cache of mirrors. Enabled automatically when $%s is set", repo.CacheEnvVar))
 	cl.AddCommand(&apply.Cmd{})
+	cl.AddCommand(&cache.Cmd{})
 	cl.AddCommand(&check.Cmd{})
+	cl.AddCommand(&export.Cmd{})
+	cl.AddCommand(&importer.Cmd{})
+	cl.AddCommand(&lint.Cmd{})
+	cl.AddCommand(&migrate.Cmd{})
 	cl.AddCommand(&record.Cmd{})
 	cl.AddCommand(&reset.Cmd{})
+	cl.AddCommand(&unstash.Cmd{})
+	cl.AddCommand(&update.Cmd{})
 	if err := cl.RunCommand(cl.Parse(os.Args[1:])); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
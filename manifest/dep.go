@@ -0,0 +1,76 @@
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+// parseDep parses a Gopkg.lock file. Only the subset of TOML that dep
+// actually writes is supported: [[projects]] tables containing string keys,
+// plus arrays, which are skipped.
+func parseDep(data []byte) ([]*Entry, error) {
+	var entries []*Entry
+	var current *Entry
+	var inArray bool
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if inArray {
+			if strings.HasSuffix(line, "]") {
+				inArray = false
+			}
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			current = nil
+			if line == "[[projects]]" {
+				current = &Entry{}
+				entries = append(entries, current)
+			}
+			continue
+		}
+		i := strings.IndexByte(line, '=')
+		if i == -1 {
+			return nil, errs.New(fmt.Sprintf("line %d: expected a key/value pair", lineNum))
+		}
+		key := strings.TrimSpace(line[:i])
+		value := strings.TrimSpace(line[i+1:])
+		if strings.HasPrefix(value, "[") {
+			inArray = !strings.HasSuffix(value, "]")
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		text, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, errs.NewWithCause(fmt.Sprintf("line %d: invalid value for %s", lineNum, key), err)
+		}
+		switch key {
+		case "name":
+			current.Import = text
+		case "revision":
+			current.Revision = text
+		case "version":
+			current.Version = text
+		case "branch":
+			current.Branch = text
+		case "source":
+			current.Remote = text
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,56 @@
+package manifest
+
+import "testing"
+
+func TestParseDep(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		data    string
+		want    []*Entry
+		wantErr bool
+	}{
+		{
+			name: "projects",
+			data: `# This file is autogenerated, do not edit; changes may be undone by the next 'dep ensure'.
+
+[[projects]]
+  digest = "1:abc"
+  name = "github.com/user/a"
+  packages = [
+    ".",
+    "sub",
+  ]
+  pruneopts = "UT"
+  revision = "1111"
+  version = "v1.0.0"
+
+[[projects]]
+  branch = "main"
+  name = "github.com/user/b"
+  packages = ["."]
+  revision = "2222"
+  source = "https://mirror.example.com/b.git"
+
+[solve-meta]
+  analyzer-name = "dep"
+  input-imports = [
+    "github.com/user/a",
+  ]
+`,
+			want: []*Entry{
+				{Import: "github.com/user/a", Revision: "1111", Version: "v1.0.0"},
+				{Import: "github.com/user/b", Revision: "2222", Branch: "main", Remote: "https://mirror.example.com/b.git"},
+			},
+		},
+		{name: "empty"},
+		{name: "missing value", data: "[[projects]]\n  name\n", wantErr: true},
+		{name: "unquoted value", data: "[[projects]]\n  name = github.com/user/a\n", wantErr: true},
+	} {
+		got, err := parseDep([]byte(test.data))
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: unexpected error state: %v", test.name, err)
+			continue
+		}
+		checkEntries(t, test.name, got, test.want)
+	}
+}
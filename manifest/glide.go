@@ -0,0 +1,42 @@
+package manifest
+
+import (
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+var revisionRegex = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+type glideLock struct {
+	Imports     []*glideImport
+	TestImports []*glideImport `yaml:"testImports"`
+}
+
+type glideImport struct {
+	Name    string
+	Version string
+	Repo    string
+}
+
+// parseGlide parses a glide.lock file. Glide records the exact revision as
+// the version, but older files may contain a tag instead.
+func parseGlide(data []byte) ([]*Entry, error) {
+	var lock glideLock
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	entries := make([]*Entry, 0, len(lock.Imports)+len(lock.TestImports))
+	for _, one := range append(lock.Imports, lock.TestImports...) {
+		e := &Entry{Import: one.Name, Remote: one.Repo}
+		if revisionRegex.MatchString(one.Version) {
+			e.Revision = one.Version
+		} else {
+			e.Version = one.Version
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
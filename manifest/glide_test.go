@@ -0,0 +1,44 @@
+package manifest
+
+import "testing"
+
+func TestParseGlide(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		data    string
+		want    []*Entry
+		wantErr bool
+	}{
+		{
+			name: "imports",
+			data: `hash: abc
+updated: 2017-01-01T00:00:00Z
+imports:
+- name: github.com/user/a
+  version: 1111111111111111111111111111111111111111
+  subpackages:
+  - sub
+- name: github.com/user/b
+  version: v1.0.0
+  repo: https://mirror.example.com/b.git
+testImports:
+- name: github.com/user/c
+  version: 3333333333333333333333333333333333333333
+`,
+			want: []*Entry{
+				{Import: "github.com/user/a", Revision: "1111111111111111111111111111111111111111"},
+				{Import: "github.com/user/b", Version: "v1.0.0", Remote: "https://mirror.example.com/b.git"},
+				{Import: "github.com/user/c", Revision: "3333333333333333333333333333333333333333"},
+			},
+		},
+		{name: "empty"},
+		{name: "malformed", data: "imports: [\n", wantErr: true},
+	} {
+		got, err := parseGlide([]byte(test.data))
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: unexpected error state: %v", test.name, err)
+			continue
+		}
+		checkEntries(t, test.name, got, test.want)
+	}
+}
@@ -0,0 +1,34 @@
+package manifest
+
+import (
+	"encoding/json"
+
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+type godeps struct {
+	Deps []struct {
+		ImportPath string
+		Comment    string
+		Rev        string
+	}
+}
+
+// parseGodep parses a Godeps/Godeps.json file. Godep stores the output of
+// 'git describe' in the comment, so it is only treated as a tag when it names
+// one exactly.
+func parseGodep(data []byte) ([]*Entry, error) {
+	var deps godeps
+	if err := json.Unmarshal(data, &deps); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	entries := make([]*Entry, 0, len(deps.Deps))
+	for _, one := range deps.Deps {
+		entries = append(entries, &Entry{
+			Import:   one.ImportPath,
+			Revision: one.Rev,
+			Version:  exactTag(one.Comment),
+		})
+	}
+	return entries, nil
+}
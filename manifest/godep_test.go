@@ -0,0 +1,39 @@
+package manifest
+
+import "testing"
+
+func TestParseGodep(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		data    string
+		want    []*Entry
+		wantErr bool
+	}{
+		{
+			name: "deps",
+			data: `{
+	"ImportPath": "github.com/user/project",
+	"GoVersion": "go1.8",
+	"Deps": [
+		{"ImportPath": "github.com/user/a", "Comment": "v1.0.0", "Rev": "1111"},
+		{"ImportPath": "github.com/user/b", "Comment": "v1.0.0-3-g2222222", "Rev": "2222"},
+		{"ImportPath": "github.com/user/c", "Rev": "3333"}
+	]
+}`,
+			want: []*Entry{
+				{Import: "github.com/user/a", Revision: "1111", Version: "v1.0.0"},
+				{Import: "github.com/user/b", Revision: "2222"},
+				{Import: "github.com/user/c", Revision: "3333"},
+			},
+		},
+		{name: "empty", data: "{}"},
+		{name: "malformed", data: "{", wantErr: true},
+	} {
+		got, err := parseGodep([]byte(test.data))
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: unexpected error state: %v", test.name, err)
+			continue
+		}
+		checkEntries(t, test.name, got, test.want)
+	}
+}
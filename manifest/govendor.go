@@ -0,0 +1,37 @@
+package manifest
+
+import (
+	"encoding/json"
+
+	"github.com/richardwilkes/gopathdep/repo"
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+type govendorFile struct {
+	Package []struct {
+		Path         string
+		Revision     string
+		Version      string
+		VersionExact string `json:"versionExact"`
+	}
+}
+
+// parseGovendor parses a vendor/vendor.json file. The exact version is a
+// tag, while a version that isn't a semantic version names a branch.
+func parseGovendor(data []byte) ([]*Entry, error) {
+	var file govendorFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	entries := make([]*Entry, 0, len(file.Package))
+	for _, one := range file.Package {
+		e := &Entry{Import: one.Path, Revision: one.Revision}
+		if one.VersionExact != "" {
+			e.Version = one.VersionExact
+		} else if _, isSemVer := repo.ParseSemVer(one.Version); one.Version != "" && !isSemVer {
+			e.Branch = one.Version
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
@@ -0,0 +1,40 @@
+package manifest
+
+import "testing"
+
+func TestParseGovendor(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		data    string
+		want    []*Entry
+		wantErr bool
+	}{
+		{
+			name: "packages",
+			data: `{
+	"comment": "",
+	"package": [
+		{"path": "github.com/user/a", "revision": "1111", "version": "v1", "versionExact": "v1.0.0"},
+		{"path": "github.com/user/b", "revision": "2222", "version": "develop"},
+		{"path": "github.com/user/c", "revision": "3333", "version": "v1.2.0"},
+		{"path": "github.com/user/d", "revision": "4444"}
+	]
+}`,
+			want: []*Entry{
+				{Import: "github.com/user/a", Revision: "1111", Version: "v1.0.0"},
+				{Import: "github.com/user/b", Revision: "2222", Branch: "develop"},
+				{Import: "github.com/user/c", Revision: "3333"},
+				{Import: "github.com/user/d", Revision: "4444"},
+			},
+		},
+		{name: "empty", data: "{}"},
+		{name: "malformed", data: "[", wantErr: true},
+	} {
+		got, err := parseGovendor([]byte(test.data))
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: unexpected error state: %v", test.name, err)
+			continue
+		}
+		checkEntries(t, test.name, got, test.want)
+	}
+}
@@ -0,0 +1,112 @@
+// Package manifest translates the manifests and lock files of other
+// dependency management tools into dependencies.
+package manifest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/richardwilkes/gopathdep/repo"
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+// Entry holds a single dependency read from another tool's manifest.
+type Entry struct {
+	Import   string
+	Revision string
+	Version  string
+	Branch   string
+	Remote   string
+}
+
+// Format describes a manifest format that can be imported.
+type Format struct {
+	Name  string
+	File  string
+	parse func(data []byte) ([]*Entry, error)
+}
+
+// Formats holds the supported manifest formats.
+var Formats = []*Format{
+	{Name: "dep", File: "Gopkg.lock", parse: parseDep},
+	{Name: "glide", File: "glide.lock", parse: parseGlide},
+	{Name: "godep", File: filepath.Join("Godeps", "Godeps.json"), parse: parseGodep},
+	{Name: "govendor", File: filepath.Join("vendor", "vendor.json"), parse: parseGovendor},
+}
+
+var describeRegex = regexp.MustCompile(`-[0-9]+(-g[0-9a-f]+)?$`)
+
+// FormatByName returns the format with the specified name, or nil.
+func FormatByName(name string) *Format {
+	for _, one := range Formats {
+		if one.Name == name {
+			return one
+		}
+	}
+	return nil
+}
+
+// Read the format's manifest from the directory, returning its entries
+// grouped by repo root.
+func (f *Format) Read(dir string) ([]*Entry, error) {
+	path := filepath.Join(dir, f.File)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errs.NewWithCause(fmt.Sprintf("Unable to read %s", path), err)
+	}
+	var entries []*Entry
+	if entries, err = f.parse(data); err != nil {
+		return nil, errs.NewWithCause(fmt.Sprintf("Unable to parse %s", path), err)
+	}
+	return groupByRoot(entries), nil
+}
+
+// Dependency returns the dependency for this entry. Versions become tags and
+// branches remain branches, with the revision being used only when neither
+// is available.
+func (e *Entry) Dependency() *repo.Dependency {
+	dep := &repo.Dependency{Import: e.Import, Remote: e.Remote}
+	switch {
+	case e.Version != "":
+		dep.Tag = e.Version
+	case e.Branch != "":
+		dep.Branch = e.Branch
+	default:
+		dep.Commit = e.Revision
+	}
+	return dep
+}
+
+func groupByRoot(entries []*Entry) []*Entry {
+	// Process shorter import paths first, so that the entry for a repo's root
+	// package is preferred over those for its sub-packages.
+	sort.SliceStable(entries, func(i, j int) bool { return len(entries[i].Import) < len(entries[j].Import) })
+	roots := make(map[string]*Entry)
+	for _, e := range entries {
+		root := repo.RootImportPath(e.Import)
+		if _, exists := roots[root]; !exists {
+			e.Import = root
+			roots[root] = e
+		}
+	}
+	result := make([]*Entry, 0, len(roots))
+	for _, e := range roots {
+		result = append(result, e)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Import < result[j].Import })
+	return result
+}
+
+// exactTag returns the text if it names a tag rather than a description of a
+// commit relative to a tag, such as those produced by 'git describe'. When in
+// doubt, an empty string is returned so that the revision will be used.
+func exactTag(text string) string {
+	if text = strings.TrimSpace(text); describeRegex.MatchString(text) {
+		return ""
+	}
+	return text
+}
@@ -0,0 +1,110 @@
+package manifest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/richardwilkes/gopathdep/repo"
+	"github.com/richardwilkes/gopathdep/util"
+)
+
+// useTempGoPath points $GOPATH at an empty directory, so that repo roots are
+// determined from the import paths alone.
+func useTempGoPath(t *testing.T) {
+	t.Helper()
+	saved := util.SrcPaths
+	src := filepath.ToSlash(filepath.Join(t.TempDir(), "src")) + "/"
+	if err := os.MkdirAll(src, 0777); err != nil {
+		t.Fatal(err)
+	}
+	util.SrcPaths = []string{src}
+	t.Cleanup(func() { util.SrcPaths = saved })
+}
+
+func checkEntries(t *testing.T, name string, got, want []*Entry) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s: got %d entries, want %d", name, len(got), len(want))
+		return
+	}
+	for i := range want {
+		if *got[i] != *want[i] {
+			t.Errorf("%s: entry %d: got %+v, want %+v", name, i, *got[i], *want[i])
+		}
+	}
+}
+
+func TestEntryDependency(t *testing.T) {
+	for _, test := range []struct {
+		entry Entry
+		want  repo.Dependency
+	}{
+		{
+			entry: Entry{Import: "a", Revision: "1111", Version: "v1.0.0", Branch: "main", Remote: "https://example.com/a"},
+			want:  repo.Dependency{Import: "a", Tag: "v1.0.0", Remote: "https://example.com/a"},
+		},
+		{entry: Entry{Import: "a", Revision: "1111", Branch: "main"}, want: repo.Dependency{Import: "a", Branch: "main"}},
+		{entry: Entry{Import: "a", Revision: "1111"}, want: repo.Dependency{Import: "a", Commit: "1111"}},
+	} {
+		if got := test.entry.Dependency(); *got != test.want {
+			t.Errorf("%+v: got %+v, want %+v", test.entry, *got, test.want)
+		}
+	}
+}
+
+func TestGroupByRoot(t *testing.T) {
+	useTempGoPath(t)
+	got := groupByRoot([]*Entry{
+		{Import: "github.com/user/b/sub", Revision: "2222"},
+		{Import: "github.com/user/a/sub", Revision: "1111"},
+		{Import: "github.com/user/b", Revision: "3333"},
+	})
+	checkEntries(t, "groupByRoot", got, []*Entry{
+		{Import: "github.com/user/a", Revision: "1111"},
+		{Import: "github.com/user/b", Revision: "3333"},
+	})
+}
+
+func TestExactTag(t *testing.T) {
+	for _, test := range []struct {
+		text string
+		want string
+	}{
+		{text: "v1.2.3", want: "v1.2.3"},
+		{text: " v1.2.3 ", want: "v1.2.3"},
+		{text: "v1.2.3-4-gabcdef0"},
+		{text: "v1.2.3-4"},
+		{text: "v1.2.3-beta", want: "v1.2.3-beta"},
+		{text: ""},
+	} {
+		if got := exactTag(test.text); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestFormatRead(t *testing.T) {
+	useTempGoPath(t)
+	dir := t.TempDir()
+	format := FormatByName("godep")
+	if format == nil || FormatByName("bogus") != nil {
+		t.Fatal("unexpected result from FormatByName")
+	}
+	if _, err := format.Read(dir); err == nil {
+		t.Error("expected an error for a missing manifest")
+	}
+	path := filepath.Join(dir, format.File)
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(`{"Deps":[{"ImportPath":"github.com/user/a/sub","Rev":"1111"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := format.Read(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEntries(t, "godep", entries, []*Entry{{Import: "github.com/user/a", Revision: "1111"}})
+}
@@ -0,0 +1,102 @@
+package repo
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Bazaar provides support for Bazaar repos. Bazaar branches live at separate
+// URLs, so dependencies kept in Bazaar may be pinned by revision number or
+// tag, but not by branch.
+var Bazaar VCS = &bzrVCS{}
+
+type bzrVCS struct {
+}
+
+func (b *bzrVCS) Name() string {
+	return "bzr"
+}
+
+func (b *bzrVCS) Dir() string {
+	return ".bzr"
+}
+
+func (b *bzrVCS) Clone(repo *Repo, remote, branchOrTag string) error {
+	root := repo.Root()
+	args := make([]string, 0, 6)
+	args = append(args, "branch", "--quiet")
+	if branchOrTag != "" {
+		args = append(args, "--revision", "tag:"+branchOrTag)
+	}
+	args = append(args, remote, filepath.Base(root))
+	command := exec.Command(b.Name(), args...)
+	command.Dir = filepath.Dir(root)
+	_, err := runWithOutput(command)
+	return err
+}
+
+// Fetch does nothing, as Bazaar can't retrieve new revisions without also
+// updating the working tree. Checkout retrieves them instead.
+func (b *bzrVCS) Fetch(repo *Repo) error {
+	return nil
+}
+
+// Checkout moves the working tree to the revision if the branch already has
+// it, which leaves the branch itself alone and works offline. Otherwise, the
+// revision is pulled from the remote, which also moves the branch to it.
+func (b *bzrVCS) Checkout(repo *Repo, revision string) error {
+	_, err := repo.Exec("update", "--quiet", "--revision", revision)
+	if err != nil && !Offline {
+		_, err = repo.Exec("pull", "--quiet", "--overwrite", "--revision", revision)
+	}
+	return err
+}
+
+// Pull brings the branch up to date with the remote, and then the working
+// tree, which may have been left at an older revision by Checkout.
+func (b *bzrVCS) Pull(repo *Repo) error {
+	_, err := repo.Exec("pull", "--quiet", "--overwrite")
+	if err == nil {
+		_, err = repo.Exec("update", "--quiet")
+	}
+	return err
+}
+
+func (b *bzrVCS) Revision(repo *Repo) (string, error) {
+	return repo.Exec("revno", "--tree")
+}
+
+func (b *bzrVCS) Tags(repo *Repo, revision string) ([]string, error) {
+	result, err := repo.Exec("tags")
+	if err != nil {
+		return nil, err
+	}
+	var tags []string
+	for _, line := range strings.Split(result, "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && (revision == "" || fields[1] == revision) {
+			tags = append(tags, fields[0])
+		}
+	}
+	return tags, nil
+}
+
+func (b *bzrVCS) Branches(repo *Repo, revision string) ([]string, error) {
+	return nil, nil
+}
+
+func (b *bzrVCS) Dirty(repo *Repo) (bool, error) {
+	result, err := repo.Exec("status", "--short", "--versioned")
+	if err != nil {
+		return true, err
+	}
+	return result != "", nil
+}
+
+func (b *bzrVCS) Origin(repo *Repo) (string, error) {
+	return repo.Exec("config", "parent_location")
+}
+
+func (b *bzrVCS) DefaultBranch(repo *Repo) (string, error) {
+	return "", nil
+}
@@ -0,0 +1,60 @@
+package repo
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBazaar(t *testing.T) {
+	requireTool(t, "bzr")
+	useTempGoPath(t)
+	t.Setenv("BZR_EMAIL", "Test <test@example.com>")
+	t.Setenv("BZR_HOME", t.TempDir())
+	origin := filepath.Join(t.TempDir(), "origin")
+	runTool(t, filepath.Dir(origin), "bzr", "init", "--quiet", origin)
+	writeTestFile(t, filepath.Join(origin, "a.go"), "package dep\n")
+	runTool(t, origin, "bzr", "add", "--quiet", "a.go")
+	runTool(t, origin, "bzr", "commit", "--quiet", "-m", "Add a.go")
+	runTool(t, origin, "bzr", "tag", "--quiet", "v1.0.0")
+	writeTestFile(t, filepath.Join(origin, "b.go"), "package dep\n\nconst B = 1\n")
+	runTool(t, origin, "bzr", "add", "--quiet", "b.go")
+	runTool(t, origin, "bzr", "commit", "--quiet", "-m", "Add b.go")
+
+	r := &Repo{ImportPath: "example.com/dep", VCS: Bazaar, RemoteURL: origin}
+	if err := r.Clone("v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	if revision, err := r.Commit(); err != nil || revision != "1" {
+		t.Errorf("got revision %s (%v) after cloning a tag, want 1", revision, err)
+	}
+	if got, err := r.System().Origin(r); err != nil || !strings.HasSuffix(strings.TrimSuffix(got, "/"), "/origin") {
+		t.Errorf("got origin %s (%v), want %s", got, err, origin)
+	}
+	if tags, err := r.System().Tags(r, "1"); err != nil || !reflect.DeepEqual(tags, []string{"v1.0.0"}) {
+		t.Errorf("got tags %v (%v) for revision 1, want [v1.0.0]", tags, err)
+	}
+	if err := r.Checkout("2"); err != nil {
+		t.Fatal(err)
+	}
+	if revision, err := r.Commit(); err != nil || revision != "2" {
+		t.Errorf("got revision %s (%v) after pulling revision 2, want 2", revision, err)
+	}
+	if err := r.Checkout("1"); err != nil {
+		t.Fatal(err)
+	}
+	if revision, err := r.Commit(); err != nil || revision != "1" {
+		t.Errorf("got revision %s (%v) after checkout, want 1", revision, err)
+	}
+	if tags, err := r.System().Tags(r, "2"); err != nil || tags != nil {
+		t.Errorf("got tags %v (%v) for revision 2, want none", tags, err)
+	}
+	if dirty, err := r.System().Dirty(r); err != nil || dirty {
+		t.Errorf("got dirty %v (%v), want clean", dirty, err)
+	}
+	writeTestFile(t, filepath.Join(r.Root(), "a.go"), "package dep\n\nconst A = 1\n")
+	if dirty, err := r.System().Dirty(r); err != nil || !dirty {
+		t.Errorf("got dirty %v (%v) after a change, want dirty", dirty, err)
+	}
+}
@@ -0,0 +1,157 @@
+package repo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/richardwilkes/gopathdep/util"
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+// CacheEnvVar is the environment variable that, when set, enables the cache
+// and specifies its location.
+const CacheEnvVar = "GOPATHDEP_CACHE"
+
+// UseCache enables the cache of bare git mirrors. When enabled, clones borrow
+// objects from a mirror of their remote and fetches are made from the
+// refreshed mirror, so that each remote only needs to be downloaded once per
+// machine.
+var UseCache = os.Getenv(CacheEnvVar) != ""
+
+// Mirror holds information about a bare mirror of a remote git repo in the
+// cache.
+type Mirror struct {
+	Dir    string
+	Remote string
+	Used   time.Time
+}
+
+// CacheDir returns the directory that holds the cache of mirrors. This is
+// $GOPATHDEP_CACHE, if set, or a gopathdep directory within the user's cache
+// directory, such as $XDG_CACHE_HOME.
+func CacheDir() string {
+	if dir := os.Getenv(CacheEnvVar); dir != "" {
+		return filepath.ToSlash(dir)
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.ToSlash(filepath.Join(dir, "gopathdep"))
+}
+
+// Mirrors returns the mirrors in the cache, sorted by remote.
+func Mirrors() ([]*Mirror, error) {
+	entries, err := ioutil.ReadDir(CacheDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errs.Wrap(err)
+	}
+	var mirrors []*Mirror
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasSuffix(entry.Name(), ".git") {
+			m := &Mirror{Dir: filepath.ToSlash(filepath.Join(CacheDir(), entry.Name())), Used: entry.ModTime()}
+			if m.Remote, err = m.git("config", "--get", "remote.origin.url"); err != nil {
+				m.Remote = ""
+			}
+			mirrors = append(mirrors, m)
+		}
+	}
+	sort.Slice(mirrors, func(i, j int) bool { return mirrors[i].Remote < mirrors[j].Remote })
+	return mirrors, nil
+}
+
+// Verify checks the integrity of the mirror.
+func (m *Mirror) Verify() error {
+	if m.Remote == "" {
+		return errs.New("no remote is configured")
+	}
+	_, err := m.git("fsck", "--connectivity-only", "--no-progress")
+	return err
+}
+
+// Remove the mirror from the cache.
+func (m *Mirror) Remove() error {
+	unlock, err := lockMirror(m.Dir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return errs.Wrap(os.RemoveAll(m.Dir))
+}
+
+func (m *Mirror) git(arg ...string) (string, error) {
+	command := exec.Command(Git.Name(), arg...)
+	command.Dir = m.Dir
+	return runWithOutput(command)
+}
+
+// mirrorDir returns the directory of the mirror for the remote.
+func mirrorDir(remote string) string {
+	sum := sha256.Sum256([]byte(normalizeRemote(remote)))
+	return filepath.ToSlash(filepath.Join(CacheDir(), hex.EncodeToString(sum[:16])+".git"))
+}
+
+// lockMirror waits for exclusive use of the mirror, which may be shared with
+// other gopathdep processes, and returns a function that gives it up. The
+// lock is held on a file next to the mirror, as git needs the mirror's own
+// directory to be empty when creating it. The lock file is left in place, as
+// removing it could let another process lock a file that is about to vanish.
+func lockMirror(dir string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(dir), 0777); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	file, err := os.OpenFile(dir+".lock", os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	if err = lockFile(file); err != nil {
+		if closeErr := file.Close(); closeErr != nil {
+			util.Ignore()
+		}
+		return nil, errs.NewWithCause("unable to lock "+dir, err)
+	}
+	return func() {
+		if err := unlockFile(file); err != nil {
+			util.Ignore()
+		}
+		if err := file.Close(); err != nil {
+			util.Ignore()
+		}
+	}, nil
+}
+
+// refreshMirror creates or updates the mirror of the remote, returning its
+// directory.
+func refreshMirror(remote string) (string, error) {
+	dir := mirrorDir(remote)
+	unlock, err := lockMirror(dir)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+	var command *exec.Cmd
+	if util.IsDir(dir) {
+		command = exec.Command(Git.Name(), "fetch", "--quiet", "--prune", "origin")
+		command.Dir = dir
+	} else {
+		command = exec.Command(Git.Name(), "clone", "--quiet", "--mirror", remote, dir)
+	}
+	if _, err = runWithOutput(command); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	if err := os.Chtimes(dir, now, now); err != nil {
+		util.Ignore()
+	}
+	return dir, nil
+}
@@ -0,0 +1,151 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/richardwilkes/gopathdep/util"
+)
+
+// setGitIdentity provides the identity git needs to make commits.
+func setGitIdentity(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(name, "Test")
+	}
+	for _, name := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(name, "test@example.com")
+	}
+	t.Setenv(CacheEnvVar, t.TempDir())
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	return runTool(t, dir, "git", args...)
+}
+
+// runTool runs the command in the directory, failing the test if it fails.
+func runTool(t *testing.T, dir, name string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s %s: %v\n%s", name, strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// requireTool skips the test if the command isn't installed.
+func requireTool(t *testing.T, name string) {
+	t.Helper()
+	if _, err := exec.LookPath(name); err != nil {
+		t.Skipf("%s is not installed", name)
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func commitFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	writeTestFile(t, filepath.Join(dir, name), content)
+	runGit(t, dir, "add", name)
+	runGit(t, dir, "commit", "--quiet", "-m", "Change "+name)
+	return runGit(t, dir, "rev-parse", "HEAD")
+}
+
+func TestMirrorDir(t *testing.T) {
+	t.Setenv(CacheEnvVar, t.TempDir())
+	want := mirrorDir("https://example.com/a")
+	for _, remote := range []string{"https://example.com/a.git", "https://example.com/a/", " https://example.com/a "} {
+		if got := mirrorDir(remote); got != want {
+			t.Errorf("%q: got %s, want %s", remote, got, want)
+		}
+	}
+	if mirrorDir("https://example.com/b") == want {
+		t.Error("different remotes should have different mirrors")
+	}
+}
+
+func TestMirrors(t *testing.T) {
+	setGitIdentity(t)
+	origin := t.TempDir()
+	runGit(t, origin, "init", "--quiet", "--initial-branch", "main")
+	commitFile(t, origin, "a.go", "package dep\n")
+	mirrors, err := Mirrors()
+	if err != nil || len(mirrors) != 0 {
+		t.Fatalf("got %v (%v) for an empty cache, want no mirrors", mirrors, err)
+	}
+	var dir string
+	if dir, err = refreshMirror(origin); err != nil {
+		t.Fatal(err)
+	}
+	commit := commitFile(t, origin, "b.go", "package dep\n")
+	if _, err = refreshMirror(origin); err != nil {
+		t.Fatal(err)
+	}
+	if got := runGit(t, dir, "rev-parse", "main"); got != commit {
+		t.Errorf("got %s after refreshing the mirror, want %s", got, commit)
+	}
+	if mirrors, err = Mirrors(); err != nil || len(mirrors) != 1 || mirrors[0].Dir != dir || mirrors[0].Remote != origin {
+		t.Fatalf("got %v (%v), want a single mirror of %s", mirrors, err, origin)
+	}
+	if err = mirrors[0].Verify(); err != nil {
+		t.Error(err)
+	}
+	if err = mirrors[0].Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if util.IsDir(dir) {
+		t.Error("the mirror was not removed")
+	}
+}
+
+func TestLockMirror(t *testing.T) {
+	t.Setenv(CacheEnvVar, t.TempDir())
+	dir := mirrorDir("https://example.com/a")
+	unlock, err := lockMirror(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	locked := make(chan func())
+	go func() {
+		// The lock is taken through a separate file, as another process
+		// would, so this waits for the file lock rather than a mutex.
+		second, lockErr := lockMirror(dir)
+		if lockErr != nil {
+			t.Error(lockErr)
+			second = func() {}
+		}
+		locked <- second
+	}()
+	select {
+	case second := <-locked:
+		second()
+		t.Fatal("the mirror was locked twice")
+	case <-time.After(100 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case second := <-locked:
+		second()
+	case <-time.After(5 * time.Second):
+		t.Fatal("the mirror was not unlocked")
+	}
+	if util.IsDir(dir) {
+		t.Error("locking the mirror should not create its directory")
+	}
+}
@@ -0,0 +1,31 @@
+package repo
+
+// CloneOptions holds options that reduce the amount of history retrieved when
+// cloning a git repo. Revisions that aren't present in the clone are fetched
+// when they are checked out.
+type CloneOptions struct {
+	Depth        int    `yaml:",omitempty"`
+	Filter       string `yaml:",omitempty"`
+	SingleBranch bool   `yaml:"single-branch,omitempty"`
+}
+
+// Merge returns a copy of these options, with any options that are set in
+// the other options taking precedence. Either may be nil.
+func (opts *CloneOptions) Merge(other *CloneOptions) *CloneOptions {
+	var merged CloneOptions
+	if opts != nil {
+		merged = *opts
+	}
+	if other != nil {
+		if other.Depth > 0 {
+			merged.Depth = other.Depth
+		}
+		if other.Filter != "" {
+			merged.Filter = other.Filter
+		}
+		if other.SingleBranch {
+			merged.SingleBranch = true
+		}
+	}
+	return &merged
+}
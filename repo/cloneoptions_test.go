@@ -0,0 +1,34 @@
+package repo
+
+import "testing"
+
+func TestCloneOptionsMerge(t *testing.T) {
+	for _, test := range []struct {
+		opts  *CloneOptions
+		other *CloneOptions
+		want  CloneOptions
+	}{
+		{},
+		{opts: &CloneOptions{Depth: 1, Filter: "blob:none"}, want: CloneOptions{Depth: 1, Filter: "blob:none"}},
+		{other: &CloneOptions{SingleBranch: true}, want: CloneOptions{SingleBranch: true}},
+		{
+			opts:  &CloneOptions{Depth: 1, Filter: "blob:none", SingleBranch: true},
+			other: &CloneOptions{Depth: 5},
+			want:  CloneOptions{Depth: 5, Filter: "blob:none", SingleBranch: true},
+		},
+		{
+			opts:  &CloneOptions{Depth: 1},
+			other: &CloneOptions{Filter: "tree:0", SingleBranch: true},
+			want:  CloneOptions{Depth: 1, Filter: "tree:0", SingleBranch: true},
+		},
+	} {
+		if got := test.opts.Merge(test.other); got == nil || *got != test.want {
+			t.Errorf("%+v merged with %+v: got %+v, want %+v", test.opts, test.other, got, test.want)
+		}
+	}
+	opts := &CloneOptions{Depth: 1}
+	opts.Merge(&CloneOptions{Depth: 2})
+	if opts.Depth != 1 {
+		t.Error("Merge should not modify the receiver")
+	}
+}
@@ -1,13 +1,16 @@
 package repo
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
+	"strings"
 
-	"gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
 
 	"github.com/richardwilkes/gopathdep/util"
 	"github.com/richardwilkes/toolbox/cmdline"
@@ -16,7 +19,7 @@ import (
 
 const (
 	// CurrentVersion of the configuration file.
-	CurrentVersion = "1.0"
+	CurrentVersion = "2.0"
 	// ConfigFileName is the configuration file name.
 	ConfigFileName = "pathdep.yaml"
 )
@@ -26,23 +29,38 @@ type Config struct {
 	Dir          string `yaml:"-"`
 	Version      string
 	Dependencies Dependencies
+	Ignore       []string       `yaml:",omitempty"`
+	Private      []*PrivateRule `yaml:",omitempty"`
+	Insecure     []string       `yaml:",omitempty"`
+	Clone        *CloneOptions  `yaml:",omitempty"`
+	Lock         *Lock          `yaml:"-"`
+	original     []byte
 }
 
 // NewConfigFromDir creates a new configuration from the configuration file in the directory.
 func NewConfigFromDir(dir string) (*Config, error) {
-	cfg := &Config{Dir: util.MustGitRootOrDir(dir)}
+	cfg := &Config{Dir: util.MustRepoRootOrDir(dir)}
 	path := filepath.ToSlash(filepath.Join(cfg.Dir, ConfigFileName))
 
 	file, err := os.Open(path)
 	if err == nil {
 		var data []byte
 		if data, err = ioutil.ReadAll(file); err == nil {
-			err = yaml.Unmarshal(data, cfg)
+			if err = cfg.parse(data); err == nil {
+				cfg.original = data
+			}
 		}
 		if closeErr := file.Close(); closeErr != nil && err == nil {
 			err = closeErr
 		}
 		err = errs.Wrap(err)
+		if err == nil {
+			if err = SetPrivateRules(cfg.Private); err == nil {
+				if err = SetInsecurePatterns(cfg.Insecure); err == nil {
+					cfg.Lock, err = NewLockFromDir(cfg.Dir)
+				}
+			}
+		}
 	} else {
 		const msg = "Unable to open %s\nTry running '%s record' to create one." // Just here to fool the linter, as I really do want an error message with punctuation.
 		err = fmt.Errorf(msg, path, cmdline.AppCmdName)
@@ -50,19 +68,78 @@ func NewConfigFromDir(dir string) (*Config, error) {
 	return cfg, err
 }
 
-// Save the configuration file.
+// NewConfigFromDirOrEmpty is like NewConfigFromDir, but returns an empty
+// configuration if the directory doesn't have a configuration file yet. Any
+// other problem loading the configuration is returned as an error.
+func NewConfigFromDirOrEmpty(dir string) (*Config, error) {
+	cfg, err := NewConfigFromDir(dir)
+	if err != nil {
+		if _, statErr := os.Stat(filepath.Join(cfg.Dir, ConfigFileName)); !os.IsNotExist(statErr) {
+			return nil, err
+		}
+		cfg = &Config{Dir: cfg.Dir}
+		cfg.Lock, err = NewLockFromDir(cfg.Dir)
+	}
+	return cfg, err
+}
+
+// Save the configuration file. If the configuration was loaded from an
+// existing file, only the entries that changed are rewritten, so that
+// comments, blank lines and unknown keys are preserved.
 func (cfg *Config) Save() error {
 	cfg.Version = CurrentVersion
 	sort.Sort(cfg.Dependencies)
-	file, err := os.Create(filepath.Join(cfg.Dir, ConfigFileName))
+	var data []byte
+	var err error
+	if cfg.original != nil {
+		data, err = editConfig(cfg.original, cfg)
+	}
+	if data == nil && err == nil {
+		data, err = marshalYAML(cfg)
+	}
 	if err == nil {
-		var data []byte
-		if data, err = yaml.Marshal(cfg); err == nil {
+		var file *os.File
+		if file, err = os.Create(filepath.Join(cfg.Dir, ConfigFileName)); err == nil {
 			_, err = file.Write(data)
-		}
-		if closeErr := file.Close(); closeErr != nil && err == nil {
-			err = closeErr
+			if closeErr := file.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+			if err == nil {
+				cfg.original = data
+			}
 		}
 	}
 	return errs.Wrap(err)
 }
+
+// IsIgnored returns true if the import path matches one of the ignore
+// patterns. Patterns may be an exact import path, a glob pattern as
+// understood by path.Match, or an import path followed by "/..." to also
+// match everything beneath it.
+func (cfg *Config) IsIgnored(importPath string) bool {
+	for _, pattern := range cfg.Ignore {
+		if pattern == importPath {
+			return true
+		}
+		if strings.HasSuffix(pattern, "/...") {
+			prefix := strings.TrimSuffix(pattern, "/...")
+			if importPath == prefix || strings.HasPrefix(importPath, prefix+"/") {
+				return true
+			}
+		} else if matched, err := path.Match(pattern, importPath); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+func marshalYAML(v interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	err := encoder.Encode(v)
+	if closeErr := encoder.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return buffer.Bytes(), err
+}
@@ -0,0 +1,69 @@
+package repo
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewConfigFromDirOrEmpty(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		data    string
+		deps    int
+		wantErr bool
+	}{
+		{name: "missing"},
+		{name: "valid", data: "version: \"2.0\"\ndependencies:\n- import: a\n  tag: v1\n", deps: 1},
+		{name: "malformed", data: "dependencies: [\n", wantErr: true},
+		{name: "future", data: "version: \"99.0\"\n", wantErr: true},
+	} {
+		dir := t.TempDir()
+		if test.data != "" {
+			if err := ioutil.WriteFile(filepath.Join(dir, ConfigFileName), []byte(test.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		cfg, err := NewConfigFromDirOrEmpty(dir)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: unexpected error state: %v", test.name, err)
+			continue
+		}
+		if !test.wantErr {
+			if len(cfg.Dependencies) != test.deps {
+				t.Errorf("%s: got %d dependencies, want %d", test.name, len(cfg.Dependencies), test.deps)
+			}
+			if cfg.Lock == nil {
+				t.Errorf("%s: lock was not loaded", test.name)
+			}
+		}
+	}
+}
+
+func TestConfigSaveKeepsVCS(t *testing.T) {
+	dir := t.TempDir()
+	data := "version: \"2.0\"\ndependencies:\n# Served by an internal Mercurial host\n- import: example.com/a\n  remote: https://hg.example.com/a\n  vcs: hg\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, ConfigFileName), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := NewConfigFromDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Dependencies) != 1 || cfg.Dependencies[0].VCS != "hg" {
+		t.Fatalf("got %+v, want a dependency with vcs hg", cfg.Dependencies)
+	}
+	cfg.Dependencies[0].VCS = "git"
+	cfg.Dependencies = append(cfg.Dependencies, &Dependency{Import: "example.com/b", Remote: "svn://svn.example.com/b", VCS: "svn"})
+	if err = cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+	var saved []byte
+	if saved, err = ioutil.ReadFile(filepath.Join(dir, ConfigFileName)); err != nil {
+		t.Fatal(err)
+	}
+	want := "version: \"2.0\"\ndependencies:\n# Served by an internal Mercurial host\n- import: example.com/a\n  remote: https://hg.example.com/a\n  vcs: git\n- import: example.com/b\n  remote: svn://svn.example.com/b\n  vcs: svn\n"
+	if string(saved) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", saved, want)
+	}
+}
@@ -0,0 +1,288 @@
+package repo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/richardwilkes/toolbox/errs"
+	"github.com/richardwilkes/toolbox/txt"
+)
+
+// SemVer holds a parsed semantic version.
+type SemVer struct {
+	Major      int
+	Minor      int
+	Patch      int
+	PreRelease string
+}
+
+type operator int
+
+const (
+	opEqual operator = iota
+	opLess
+	opLessOrEqual
+	opGreater
+	opGreaterOrEqual
+)
+
+type comparison struct {
+	op      operator
+	version SemVer
+}
+
+// Constraint holds a semantic version constraint, such as "^1.2", "~2.3.0"
+// or ">=1.4 <2". Space-separated terms must all be satisfied, while terms
+// separated by "||" provide alternatives.
+type Constraint struct {
+	text         string
+	alternatives [][]comparison
+}
+
+// ParseSemVer parses a tag such as "v1.2.3", "1.2" or "v2.0.0-beta.1" into a
+// semantic version. Missing minor and patch numbers are treated as zero.
+func ParseSemVer(tag string) (SemVer, bool) {
+	v, _, ok := parseSemVer(tag)
+	return v, ok
+}
+
+// parseSemVer also returns the number of version components that were
+// explicitly present, which constraints use to widen partial versions.
+func parseSemVer(text string) (SemVer, int, bool) {
+	var v SemVer
+	text = strings.TrimPrefix(strings.TrimPrefix(text, "v"), "V")
+	if i := strings.IndexByte(text, '+'); i != -1 {
+		text = text[:i]
+	}
+	if i := strings.IndexByte(text, '-'); i != -1 {
+		v.PreRelease = text[i+1:]
+		if v.PreRelease == "" {
+			return v, 0, false
+		}
+		text = text[:i]
+	}
+	parts := strings.Split(text, ".")
+	if len(parts) > 3 {
+		return v, 0, false
+	}
+	count := 0
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, 0, false
+		}
+		switch i {
+		case 0:
+			v.Major = n
+		case 1:
+			v.Minor = n
+		case 2:
+			v.Patch = n
+		}
+		count++
+	}
+	if count == 0 && (len(parts) != 1 || v.PreRelease != "") {
+		return v, 0, false
+	}
+	return v, count, true
+}
+
+// Compare returns -1, 0 or 1 depending on whether this version is less than,
+// equal to or greater than the other version.
+func (v SemVer) Compare(other SemVer) int {
+	switch {
+	case v.Major != other.Major:
+		return compareInts(v.Major, other.Major)
+	case v.Minor != other.Minor:
+		return compareInts(v.Minor, other.Minor)
+	case v.Patch != other.Patch:
+		return compareInts(v.Patch, other.Patch)
+	case v.PreRelease == other.PreRelease:
+		return 0
+	case v.PreRelease == "":
+		return 1
+	case other.PreRelease == "":
+		return -1
+	case txt.NaturalLess(v.PreRelease, other.PreRelease, true):
+		return -1
+	default:
+		return 1
+	}
+}
+
+func (v SemVer) String() string {
+	if v.PreRelease != "" {
+		return fmt.Sprintf("%d.%d.%d-%s", v.Major, v.Minor, v.Patch, v.PreRelease)
+	}
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+func compareInts(a, b int) int {
+	if a < b {
+		return -1
+	}
+	return 1
+}
+
+// ParseConstraint parses a semantic version constraint.
+func ParseConstraint(text string) (*Constraint, error) {
+	c := &Constraint{text: text}
+	for _, alt := range strings.Split(text, "||") {
+		fields := strings.Fields(alt)
+		if len(fields) == 0 {
+			return nil, errs.New(fmt.Sprintf("invalid version constraint '%s'", text))
+		}
+		var terms []comparison
+		for _, field := range fields {
+			more, err := parseTerm(field)
+			if err != nil {
+				return nil, errs.NewWithCause(fmt.Sprintf("invalid version constraint '%s'", text), err)
+			}
+			terms = append(terms, more...)
+		}
+		c.alternatives = append(c.alternatives, terms)
+	}
+	return c, nil
+}
+
+func parseTerm(term string) ([]comparison, error) {
+	var prefix string
+	for _, one := range []string{">=", "<=", "==", "=", ">", "<", "^", "~"} {
+		if strings.HasPrefix(term, one) {
+			prefix = one
+			break
+		}
+	}
+	v, count, ok := parseSemVer(strings.TrimPrefix(term, prefix))
+	if !ok {
+		return nil, errs.New(fmt.Sprintf("'%s' is not a valid version", term))
+	}
+	switch prefix {
+	case ">":
+		if count < 3 {
+			return []comparison{{op: opGreaterOrEqual, version: bump(v, count)}}, nil
+		}
+		return []comparison{{op: opGreater, version: v}}, nil
+	case ">=":
+		return []comparison{{op: opGreaterOrEqual, version: v}}, nil
+	case "<":
+		return []comparison{{op: opLess, version: v}}, nil
+	case "<=":
+		if count < 3 {
+			return []comparison{{op: opLess, version: bump(v, count)}}, nil
+		}
+		return []comparison{{op: opLessOrEqual, version: v}}, nil
+	case "^":
+		upper := count
+		switch {
+		case v.Major != 0 || count < 2:
+			upper = 1
+		case v.Minor != 0 || count < 3:
+			upper = 2
+		}
+		return []comparison{{op: opGreaterOrEqual, version: v}, {op: opLess, version: bump(v, upper)}}, nil
+	case "~":
+		upper := count
+		if upper > 2 {
+			upper = 2
+		}
+		return []comparison{{op: opGreaterOrEqual, version: v}, {op: opLess, version: bump(v, upper)}}, nil
+	default:
+		if count == 0 {
+			return nil, nil
+		}
+		if count < 3 {
+			return []comparison{{op: opGreaterOrEqual, version: v}, {op: opLess, version: bump(v, count)}}, nil
+		}
+		return []comparison{{op: opEqual, version: v}}, nil
+	}
+}
+
+// bump returns the smallest version that is greater than every version
+// sharing the first 'count' components with v.
+func bump(v SemVer, count int) SemVer {
+	switch count {
+	case 0:
+		return SemVer{Major: int(^uint(0) >> 1)}
+	case 1:
+		return SemVer{Major: v.Major + 1}
+	case 2:
+		return SemVer{Major: v.Major, Minor: v.Minor + 1}
+	default:
+		return SemVer{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+	}
+}
+
+func (c *Constraint) String() string {
+	return c.text
+}
+
+// Matches returns true if the tag is a semantic version that satisfies the
+// constraint. Pre-release versions only match when the constraint itself
+// mentions a pre-release of the same major, minor and patch version.
+func (c *Constraint) Matches(tag string) bool {
+	v, ok := ParseSemVer(tag)
+	if !ok {
+		return false
+	}
+	for _, alt := range c.alternatives {
+		if c.satisfies(alt, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Constraint) satisfies(terms []comparison, v SemVer) bool {
+	allowPreRelease := v.PreRelease == ""
+	for _, term := range terms {
+		cmp := v.Compare(term.version)
+		switch term.op {
+		case opEqual:
+			if cmp != 0 {
+				return false
+			}
+		case opLess:
+			if cmp >= 0 {
+				return false
+			}
+		case opLessOrEqual:
+			if cmp > 0 {
+				return false
+			}
+		case opGreater:
+			if cmp <= 0 {
+				return false
+			}
+		case opGreaterOrEqual:
+			if cmp < 0 {
+				return false
+			}
+		}
+		if !allowPreRelease && term.version.PreRelease != "" && term.version.Major == v.Major && term.version.Minor == v.Minor && term.version.Patch == v.Patch {
+			allowPreRelease = true
+		}
+	}
+	return allowPreRelease
+}
+
+// Highest returns the highest tag that satisfies the constraint, or an empty
+// string if none do.
+func (c *Constraint) Highest(tags []string) string {
+	var best string
+	var bestVersion SemVer
+	for _, tag := range tags {
+		if c.Matches(tag) {
+			v, _ := ParseSemVer(tag)
+			if best == "" || v.Compare(bestVersion) > 0 {
+				best = tag
+				bestVersion = v
+			}
+		}
+	}
+	return best
+}
@@ -0,0 +1,115 @@
+package repo
+
+import "testing"
+
+func TestParseSemVer(t *testing.T) {
+	for _, test := range []struct {
+		tag  string
+		want SemVer
+		ok   bool
+	}{
+		{tag: "v1.2.3", want: SemVer{Major: 1, Minor: 2, Patch: 3}, ok: true},
+		{tag: "1.2", want: SemVer{Major: 1, Minor: 2}, ok: true},
+		{tag: "V3", want: SemVer{Major: 3}, ok: true},
+		{tag: "v2.0.0-beta.1", want: SemVer{Major: 2, PreRelease: "beta.1"}, ok: true},
+		{tag: "v1.0.0+build.5", want: SemVer{Major: 1}, ok: true},
+		{tag: "v1.0.0-", ok: false},
+		{tag: "v1.2.3.4", ok: false},
+		{tag: "release", ok: false},
+		{tag: "v1.-2", ok: false},
+	} {
+		got, ok := ParseSemVer(test.tag)
+		if ok != test.ok || (ok && got != test.want) {
+			t.Errorf("%s: got %+v, %v, want %+v, %v", test.tag, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestSemVerCompare(t *testing.T) {
+	for _, test := range []struct {
+		a, b string
+		want int
+	}{
+		{a: "v1.2.3", b: "v1.2.3", want: 0},
+		{a: "v1.2.3", b: "v1.2.4", want: -1},
+		{a: "v1.10.0", b: "v1.9.0", want: 1},
+		{a: "v2.0.0", b: "v1.99.99", want: 1},
+		{a: "v1.0.0-rc.1", b: "v1.0.0", want: -1},
+		{a: "v1.0.0", b: "v1.0.0-rc.1", want: 1},
+	} {
+		a, _ := ParseSemVer(test.a)
+		b, _ := ParseSemVer(test.b)
+		if got := a.Compare(b); got != test.want {
+			t.Errorf("%s vs %s: got %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestParseConstraintErrors(t *testing.T) {
+	for _, text := range []string{"", "   ", "^x.y", ">=1.2 ||", "~1.2.3.4", ">=release"} {
+		if _, err := ParseConstraint(text); err == nil {
+			t.Errorf("%q: expected an error", text)
+		}
+	}
+}
+
+func TestConstraintMatches(t *testing.T) {
+	for _, test := range []struct {
+		constraint string
+		tag        string
+		want       bool
+	}{
+		{constraint: "^1.2", tag: "v1.2.0", want: true},
+		{constraint: "^1.2", tag: "v1.9.9", want: true},
+		{constraint: "^1.2", tag: "v2.0.0", want: false},
+		{constraint: "^1.2", tag: "v1.1.9", want: false},
+		{constraint: "^0.2.3", tag: "v0.2.9", want: true},
+		{constraint: "^0.2.3", tag: "v0.3.0", want: false},
+		{constraint: "~2.3.0", tag: "v2.3.7", want: true},
+		{constraint: "~2.3.0", tag: "v2.4.0", want: false},
+		{constraint: ">=1.4 <2", tag: "v1.4.0", want: true},
+		{constraint: ">=1.4 <2", tag: "v2.0.0", want: false},
+		{constraint: ">1.4", tag: "v1.4.9", want: false},
+		{constraint: ">1.4", tag: "v1.5.0", want: true},
+		{constraint: "<=1.4", tag: "v1.4.9", want: true},
+		{constraint: "1.2", tag: "v1.2.5", want: true},
+		{constraint: "1.2", tag: "v1.3.0", want: false},
+		{constraint: "=1.2.3", tag: "v1.2.3", want: true},
+		{constraint: "1.x", tag: "v1.7.0", want: true},
+		{constraint: "^1 || ^3", tag: "v3.1.0", want: true},
+		{constraint: "^1 || ^3", tag: "v2.1.0", want: false},
+		{constraint: "^1.0.0", tag: "v1.1.0-beta", want: false},
+		{constraint: ">=1.1.0-beta", tag: "v1.1.0-rc", want: true},
+		{constraint: "^1", tag: "release-1", want: false},
+	} {
+		c, err := ParseConstraint(test.constraint)
+		if err != nil {
+			t.Errorf("%s: %v", test.constraint, err)
+			continue
+		}
+		if got := c.Matches(test.tag); got != test.want {
+			t.Errorf("%s matching %s: got %v, want %v", test.constraint, test.tag, got, test.want)
+		}
+	}
+}
+
+func TestConstraintHighest(t *testing.T) {
+	tags := []string{"v1.0.0", "v1.2.0", "v1.10.0", "v2.0.0", "v2.1.0-beta", "latest"}
+	for _, test := range []struct {
+		constraint string
+		want       string
+	}{
+		{constraint: "^1", want: "v1.10.0"},
+		{constraint: "~1.2", want: "v1.2.0"},
+		{constraint: ">=2", want: "v2.0.0"},
+		{constraint: "^3", want: ""},
+	} {
+		c, err := ParseConstraint(test.constraint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.Highest(tags); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.constraint, got, test.want)
+		}
+	}
+}
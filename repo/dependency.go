@@ -1,9 +1,64 @@
 package repo
 
+import (
+	"fmt"
+
+	"github.com/richardwilkes/toolbox/errs"
+)
+
 // Dependency holds dependency information.
 type Dependency struct {
-	Import string
-	Commit string `json:",omitempty" yaml:",omitempty"`
-	Tag    string `json:",omitempty" yaml:",omitempty"`
-	Branch string `json:",omitempty" yaml:",omitempty"`
+	Import  string
+	Commit  string `json:",omitempty" yaml:",omitempty"`
+	Tag     string `json:",omitempty" yaml:",omitempty"`
+	Branch  string `json:",omitempty" yaml:",omitempty"`
+	Version string `json:",omitempty" yaml:",omitempty"`
+	Remote  string `json:",omitempty" yaml:",omitempty"`
+	VCS     string `json:",omitempty" yaml:"vcs,omitempty"`
+	line    int
+}
+
+// Line returns the line in the configuration file that the dependency was
+// read from, or 0 if it wasn't read from a file.
+func (dep *Dependency) Line() int {
+	return dep.line
+}
+
+// fields returns the keys and values of the fields that select a revision or
+// say where to find it, in the order they are written to the configuration
+// file.
+func (dep *Dependency) fields() []field {
+	return []field{
+		{key: "commit", value: dep.Commit},
+		{key: "tag", value: dep.Tag},
+		{key: "branch", value: dep.Branch},
+		{key: "version", value: dep.Version},
+		{key: "remote", value: dep.Remote},
+		{key: "vcs", value: dep.VCS},
+	}
+}
+
+// Constraint returns the parsed version constraint, or nil if the dependency
+// doesn't have one.
+func (dep *Dependency) Constraint() (*Constraint, error) {
+	if dep.Version == "" {
+		return nil, nil
+	}
+	return ParseConstraint(dep.Version)
+}
+
+// ResolveVersion returns the highest of the tags that satisfies the
+// dependency's version constraint.
+func (dep *Dependency) ResolveVersion(tags []string) (string, error) {
+	constraint, err := dep.Constraint()
+	if err != nil {
+		return "", err
+	}
+	if constraint == nil {
+		return "", nil
+	}
+	if tag := constraint.Highest(tags); tag != "" {
+		return tag, nil
+	}
+	return "", errs.New(fmt.Sprintf("no tag satisfies version %s", dep.Version))
 }
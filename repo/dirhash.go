@@ -0,0 +1,72 @@
+package repo
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/richardwilkes/gopathdep/util"
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+// hashDir computes the "h1:" checksum of the files within the directory, as
+// recorded in go.sum for a module zip whose files have the prefix
+// "module@version/". The proxy metadata directory is not included.
+func hashDir(dir, prefix string) (string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, walkerErr error) error {
+		if walkerErr != nil {
+			return walkerErr
+		}
+		if info.IsDir() {
+			if info.Name() == proxyDir && path != dir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, relErr := filepath.Rel(dir, path)
+		if relErr != nil {
+			return relErr
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return "", errs.Wrap(err)
+	}
+	sort.Strings(files)
+	summary := sha256.New()
+	for _, file := range files {
+		if strings.Contains(file, "\n") {
+			return "", errs.New(fmt.Sprintf("file names with newlines are not supported: %q", file))
+		}
+		var sum string
+		if sum, err = hashFile(filepath.Join(dir, filepath.FromSlash(file))); err != nil {
+			return "", err
+		}
+		fmt.Fprintf(summary, "%s  %s\n", sum, prefix+"/"+file)
+	}
+	return "h1:" + base64.StdEncoding.EncodeToString(summary.Sum(nil)), nil
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", errs.Wrap(err)
+	}
+	defer func() {
+		if err = f.Close(); err != nil {
+			util.Ignore()
+		}
+	}()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", errs.Wrap(err)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
@@ -0,0 +1,20 @@
+package repo
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestHashDir(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "a.txt"), "hello\n")
+	writeTestFile(t, filepath.Join(dir, "sub", "b.go"), "package b\n")
+	writeTestFile(t, filepath.Join(dir, proxyDir, "info.json"), "{}\n")
+	got, err := hashDir(dir, "m@v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "h1:F25Oa9N9nSK7OJh3BVubzO1Vx61P78SSRKHWsibLPt4="; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
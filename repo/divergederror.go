@@ -0,0 +1,16 @@
+package repo
+
+import "fmt"
+
+// DivergedError is returned when a branch can't be fast-forwarded, as it has
+// commits that its upstream branch does not.
+type DivergedError struct {
+	ImportPath string
+	Branch     string
+	Ahead      int
+	Behind     int
+}
+
+func (e *DivergedError) Error() string {
+	return fmt.Sprintf("branch %s of %s has diverged from its upstream branch, with %d local and %d remote commits; resolve it by hand or use the '%s' update policy to discard the local commits", e.Branch, e.ImportPath, e.Ahead, e.Behind, ResetToRemote)
+}
@@ -0,0 +1,428 @@
+package repo
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/richardwilkes/toolbox/errs"
+	"gopkg.in/yaml.v3"
+)
+
+// document holds the original text of a configuration file, allowing it to
+// be edited in place so that comments, blank lines, ordering and unknown keys
+// survive a round trip. Only the lines belonging to values that actually
+// changed are touched.
+type document struct {
+	lines   []string
+	root    *yaml.Node
+	replace map[int]string
+	remove  map[int]bool
+	before  map[int][]string
+	after   map[int][]string
+}
+
+type field struct {
+	key   string
+	value string
+}
+
+func newDocument(data []byte) *document {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode || doc.Content[0].Style&yaml.FlowStyle != 0 {
+		return nil
+	}
+	return &document{
+		lines:   strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"),
+		root:    doc.Content[0],
+		replace: make(map[int]string),
+		remove:  make(map[int]bool),
+		before:  make(map[int][]string),
+		after:   make(map[int][]string),
+	}
+}
+
+// editConfig returns the original configuration text updated to reflect the
+// configuration, or nil if the original text has a structure that can't be
+// edited in place. An error is returned if an import is listed more than
+// once, as there would be no way to tell which entry to edit.
+func editConfig(original []byte, cfg *Config) ([]byte, error) {
+	seen := make(map[string]bool, len(cfg.Dependencies))
+	for _, dep := range cfg.Dependencies {
+		if seen[dep.Import] {
+			return nil, errs.New(fmt.Sprintf("unable to save %s, as %s is listed more than once", ConfigFileName, dep.Import))
+		}
+		seen[dep.Import] = true
+	}
+	doc := newDocument(original)
+	if doc == nil {
+		return nil, nil
+	}
+	if _, value := mappingValue(doc.root, "version"); value != nil {
+		if !doc.setScalar(value, cfg.Version) {
+			return nil, nil
+		}
+	} else {
+		doc.before[doc.root.Line-1] = append(doc.before[doc.root.Line-1], "version: "+formatScalar(cfg.Version))
+	}
+	if !doc.editDependencies(cfg.Dependencies) {
+		return nil, nil
+	}
+	for _, f := range []struct {
+		key   string
+		value interface{}
+	}{
+		{key: "ignore", value: cfg.Ignore},
+		{key: "private", value: cfg.Private},
+		{key: "insecure", value: cfg.Insecure},
+		{key: "clone", value: cfg.Clone},
+	} {
+		if !doc.editValue(f.key, f.value) {
+			return nil, nil
+		}
+	}
+	return doc.bytes(), nil
+}
+
+// editValue rewrites a top-level key and its value if the value differs from
+// the one in the original text, adding the key at the end if it wasn't there
+// and removing it if the value is now empty.
+func (doc *document) editValue(key string, value interface{}) bool {
+	empty := isEmptyValue(reflect.ValueOf(value))
+	keyNode, valueNode := mappingValue(doc.root, key)
+	if keyNode == nil {
+		if empty {
+			return true
+		}
+		lines := formatValue(key, value, "")
+		if lines == nil {
+			return false
+		}
+		last := len(doc.lines) - 1
+		doc.after[last] = append(doc.after[last], lines...)
+		return true
+	}
+	existing := reflect.New(reflect.TypeOf(value))
+	if err := valueNode.Decode(existing.Interface()); err == nil {
+		if current := existing.Elem(); (empty && isEmptyValue(current)) || reflect.DeepEqual(current.Interface(), value) {
+			return true
+		}
+	}
+	start := keyNode.Line - 1
+	end := lastLine(valueNode)
+	if end <= start {
+		end = start + 1
+	}
+	if !empty {
+		lines := formatValue(key, value, strings.Repeat(" ", keyNode.Column-1))
+		if lines == nil {
+			return false
+		}
+		doc.before[start] = append(doc.before[start], lines...)
+	}
+	for i := start; i < end; i++ {
+		doc.remove[i] = true
+	}
+	return true
+}
+
+func isEmptyValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Slice:
+		return value.Len() == 0
+	case reflect.Ptr:
+		return value.IsNil()
+	default:
+		return false
+	}
+}
+
+// formatValue returns the lines for a top-level key and its value, or nil if
+// the value can't be marshaled.
+func formatValue(key string, value interface{}, indent string) []string {
+	data, err := marshalYAML(map[string]interface{}{key: value})
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	for i := range lines {
+		lines[i] = indent + lines[i]
+	}
+	return lines
+}
+
+func (doc *document) editDependencies(deps Dependencies) bool {
+	key, value := mappingValue(doc.root, "dependencies")
+	var items []*yaml.Node
+	var dashIndent, keyIndent string
+	switch {
+	case value == nil:
+		if len(deps) == 0 {
+			return true
+		}
+		last := len(doc.lines) - 1
+		doc.after[last] = append(doc.after[last], "dependencies:")
+		keyIndent = "  "
+	case value.Kind == yaml.SequenceNode && value.Style&yaml.FlowStyle == 0:
+		items = value.Content
+	case (value.Kind == yaml.SequenceNode && len(value.Content) == 0) || (value.Kind == yaml.ScalarNode && value.Tag == "!!null"):
+		if key.Line != value.Line && value.Kind == yaml.SequenceNode {
+			return false
+		}
+		line := key.Line - 1
+		doc.replace[line] = doc.lines[line][:key.Column-1] + "dependencies:"
+		dashIndent = strings.Repeat(" ", key.Column-1)
+		keyIndent = dashIndent + "  "
+	default:
+		return false
+	}
+	existing := make(map[string]*yaml.Node, len(items))
+	var order []string
+	for _, item := range items {
+		if item.Kind != yaml.MappingNode || item.Style&yaml.FlowStyle != 0 {
+			return false
+		}
+		if _, imp := mappingValue(item, "import"); imp != nil && imp.Kind == yaml.ScalarNode {
+			if _, dup := existing[imp.Value]; !dup {
+				existing[imp.Value] = item
+				order = append(order, imp.Value)
+			}
+		}
+	}
+	if len(items) > 0 {
+		first := items[0]
+		line := doc.lines[first.Line-1]
+		if i := strings.IndexByte(line, '-'); i != -1 && i < first.Column-1 {
+			dashIndent = line[:i]
+		}
+		keyIndent = strings.Repeat(" ", first.Column-1)
+	}
+	wanted := make(map[string]*Dependency, len(deps))
+	for _, dep := range deps {
+		wanted[dep.Import] = dep
+	}
+
+	// Update or remove the entries that are already present
+	for _, item := range items {
+		var dep *Dependency
+		if _, imp := mappingValue(item, "import"); imp != nil && existing[imp.Value] == item {
+			dep = wanted[imp.Value]
+		}
+		if dep == nil {
+			doc.removeItem(item, doc.itemStart(item))
+		} else if !doc.editItem(item, dep, keyIndent) {
+			// Too complex to edit in place, so replace the entry while
+			// retaining the comments above it.
+			doc.removeItem(item, item.Line-1)
+			doc.before[item.Line-1] = append(doc.before[item.Line-1], formatItem(dep, dashIndent, keyIndent)...)
+		}
+	}
+
+	// Insert new entries in sorted position relative to the existing ones
+	sorted := make(Dependencies, len(deps))
+	copy(sorted, deps)
+	sort.Sort(sorted)
+	for _, dep := range sorted {
+		if _, exists := existing[dep.Import]; exists {
+			continue
+		}
+		lines := formatItem(dep, dashIndent, keyIndent)
+		inserted := false
+		for _, imp := range order {
+			if _, kept := wanted[imp]; kept && imp > dep.Import {
+				start := doc.itemStart(existing[imp])
+				doc.before[start] = append(doc.before[start], lines...)
+				inserted = true
+				break
+			}
+		}
+		if !inserted {
+			var last int
+			switch {
+			case len(items) > 0:
+				last = lastLine(items[len(items)-1]) - 1
+			case value == nil:
+				last = len(doc.lines) - 1
+			default:
+				last = key.Line - 1
+			}
+			doc.after[last] = append(doc.after[last], lines...)
+		}
+	}
+	return true
+}
+
+// editItem updates an existing entry in place, returning false without making
+// any changes if that isn't possible.
+func (doc *document) editItem(item *yaml.Node, dep *Dependency, keyIndent string) bool {
+	var added []string
+	var removed []int
+	changed := make(map[*yaml.Node]string)
+	for _, f := range dep.fields() {
+		key, value := mappingValue(item, f.key)
+		switch {
+		case value == nil:
+			if f.value != "" {
+				added = append(added, keyIndent+f.key+": "+formatScalar(f.value))
+			}
+		case value.Kind != yaml.ScalarNode || value.Line != key.Line || value.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0:
+			return false
+		case f.value == "":
+			if key.Line == item.Line {
+				return false
+			}
+			removed = append(removed, key.Line-1)
+		case value.Value != f.value:
+			changed[value] = f.value
+		}
+	}
+	for node := range changed {
+		if scalarEnd(doc.lines[node.Line-1], node.Column-1, node) < 0 {
+			return false
+		}
+	}
+	for node, value := range changed {
+		doc.setScalar(node, value)
+	}
+	for _, line := range removed {
+		doc.remove[line] = true
+	}
+	last := lastLine(item) - 1
+	doc.after[last] = append(doc.after[last], added...)
+	return true
+}
+
+func (doc *document) removeItem(item *yaml.Node, start int) {
+	end := lastLine(item)
+	for i := start; i < end; i++ {
+		doc.remove[i] = true
+	}
+	// Avoid leaving behind two blank lines in a row
+	if start > 0 && end < len(doc.lines) && strings.TrimSpace(doc.lines[start-1]) == "" && strings.TrimSpace(doc.lines[end]) == "" {
+		doc.remove[end] = true
+	}
+}
+
+// itemStart returns the index of the first line of an item, including any
+// comment lines directly above it.
+func (doc *document) itemStart(item *yaml.Node) int {
+	start := item.Line - 1
+	for start > 0 && strings.HasPrefix(strings.TrimSpace(doc.lines[start-1]), "#") {
+		start--
+	}
+	return start
+}
+
+// setScalar replaces the text of a single-line scalar value, preserving
+// anything that follows it on the same line.
+func (doc *document) setScalar(node *yaml.Node, value string) bool {
+	if node.Kind != yaml.ScalarNode || node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+		return false
+	}
+	i := node.Line - 1
+	line := doc.lines[i]
+	if r, exists := doc.replace[i]; exists {
+		line = r
+	}
+	start := node.Column - 1
+	end := scalarEnd(line, start, node)
+	if end < 0 {
+		return false
+	}
+	doc.replace[i] = line[:start] + formatScalar(value) + line[end:]
+	return true
+}
+
+func scalarEnd(line string, start int, node *yaml.Node) int {
+	if start >= len(line) {
+		return -1
+	}
+	switch {
+	case node.Style&yaml.SingleQuotedStyle != 0:
+		for i := start + 1; i < len(line); i++ {
+			if line[i] == '\'' {
+				if i+1 < len(line) && line[i+1] == '\'' {
+					i++
+				} else {
+					return i + 1
+				}
+			}
+		}
+	case node.Style&yaml.DoubleQuotedStyle != 0:
+		for i := start + 1; i < len(line); i++ {
+			switch line[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1
+			}
+		}
+	default:
+		if strings.HasPrefix(line[start:], node.Value) {
+			return start + len(node.Value)
+		}
+	}
+	return -1
+}
+
+func (doc *document) bytes() []byte {
+	var buffer bytes.Buffer
+	for i, line := range doc.lines {
+		for _, one := range doc.before[i] {
+			buffer.WriteString(one)
+			buffer.WriteByte('\n')
+		}
+		if !doc.remove[i] {
+			if r, exists := doc.replace[i]; exists {
+				line = r
+			}
+			buffer.WriteString(line)
+			buffer.WriteByte('\n')
+		}
+		for _, one := range doc.after[i] {
+			buffer.WriteString(one)
+			buffer.WriteByte('\n')
+		}
+	}
+	return buffer.Bytes()
+}
+
+func formatItem(dep *Dependency, dashIndent, keyIndent string) []string {
+	lines := []string{dashIndent + "- import: " + formatScalar(dep.Import)}
+	for _, f := range dep.fields() {
+		if f.value != "" {
+			lines = append(lines, keyIndent+f.key+": "+formatScalar(f.value))
+		}
+	}
+	return lines
+}
+
+func formatScalar(value string) string {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return value
+	}
+	return strings.TrimSuffix(string(data), "\n")
+}
+
+func mappingValue(mapping *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// lastLine returns the last line number occupied by a node.
+func lastLine(node *yaml.Node) int {
+	line := node.Line
+	for _, child := range node.Content {
+		if one := lastLine(child); one > line {
+			line = one
+		}
+	}
+	return line
+}
@@ -0,0 +1,123 @@
+package repo
+
+import "testing"
+
+func TestEditConfig(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		original string
+		deps     Dependencies
+		ignore   []string
+		private  []*PrivateRule
+		insecure []string
+		clone    *CloneOptions
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "unchanged",
+			original: "# Top comment\nversion: \"2.0\"\ndependencies:\n  # About a\n  - import: a\n    tag: v1 # pinned\n",
+			deps:     Dependencies{{Import: "a", Tag: "v1"}},
+			want:     "# Top comment\nversion: \"2.0\"\ndependencies:\n  # About a\n  - import: a\n    tag: v1 # pinned\n",
+		},
+		{
+			name:     "changed value keeps trailing comment",
+			original: "version: \"2.0\"\ndependencies:\n- import: a\n  tag: v1 # pinned\n",
+			deps:     Dependencies{{Import: "a", Tag: "v2"}},
+			want:     "version: \"2.0\"\ndependencies:\n- import: a\n  tag: v2 # pinned\n",
+		},
+		{
+			name:     "switched selector",
+			original: "version: \"2.0\"\ndependencies:\n- import: a\n  tag: v1\n",
+			deps:     Dependencies{{Import: "a", Branch: "main"}},
+			want:     "version: \"2.0\"\ndependencies:\n- import: a\n  branch: main\n",
+		},
+		{
+			name:     "inserted in sorted position",
+			original: "version: \"2.0\"\ndependencies:\n- import: a\n  tag: v1\n# About c\n- import: c\n  tag: v3\n",
+			deps:     Dependencies{{Import: "a", Tag: "v1"}, {Import: "c", Tag: "v3"}, {Import: "b", Branch: "main"}},
+			want:     "version: \"2.0\"\ndependencies:\n- import: a\n  tag: v1\n- import: b\n  branch: main\n# About c\n- import: c\n  tag: v3\n",
+		},
+		{
+			name:     "appended at the end",
+			original: "version: \"2.0\"\ndependencies:\n  - import: a\n    tag: v1\n",
+			deps:     Dependencies{{Import: "a", Tag: "v1"}, {Import: "b", Tag: "v2"}},
+			want:     "version: \"2.0\"\ndependencies:\n  - import: a\n    tag: v1\n  - import: b\n    tag: v2\n",
+		},
+		{
+			name:     "removed with its comment",
+			original: "version: \"2.0\"\ndependencies:\n- import: a\n  tag: v1\n# About b\n- import: b\n  tag: v2\n",
+			deps:     Dependencies{{Import: "a", Tag: "v1"}},
+			want:     "version: \"2.0\"\ndependencies:\n- import: a\n  tag: v1\n",
+		},
+		{
+			name:     "version added and unknown keys kept",
+			original: "extra: true\ndependencies:\n- import: a\n  tag: v1\n",
+			deps:     Dependencies{{Import: "a", Tag: "v1"}},
+			want:     "version: \"2.0\"\nextra: true\ndependencies:\n- import: a\n  tag: v1\n",
+		},
+		{
+			name:     "empty dependencies",
+			original: "version: \"2.0\"\ndependencies: []\n",
+			deps:     Dependencies{{Import: "a", Tag: "v1"}},
+			want:     "version: \"2.0\"\ndependencies:\n- import: a\n  tag: v1\n",
+		},
+		{
+			name:     "flow style can't be edited",
+			original: "{version: \"2.0\"}\n",
+		},
+		{
+			name:     "flow style entries can't be edited",
+			original: "version: \"2.0\"\ndependencies:\n- {import: a, tag: v1}\n",
+			deps:     Dependencies{{Import: "a", Tag: "v2"}},
+		},
+		{
+			name:     "other keys unchanged",
+			original: "version: \"2.0\"\n# Skipped\nignore:\n- b # vendored\ninsecure: [c]\nclone:\n  depth: 1\n",
+			ignore:   []string{"b"},
+			insecure: []string{"c"},
+			clone:    &CloneOptions{Depth: 1},
+			want:     "version: \"2.0\"\n# Skipped\nignore:\n- b # vendored\ninsecure: [c]\nclone:\n  depth: 1\n",
+		},
+		{
+			name:     "other keys changed",
+			original: "version: \"2.0\"\n# Skipped\nignore:\n- b\ninsecure: [c]\nclone:\n  depth: 1\n# End\n",
+			ignore:   []string{"b", "d"},
+			clone:    &CloneOptions{Depth: 1, SingleBranch: true},
+			want:     "version: \"2.0\"\n# Skipped\nignore:\n  - b\n  - d\nclone:\n  depth: 1\n  single-branch: true\n# End\n",
+		},
+		{
+			name:     "other keys added",
+			original: "version: \"2.0\"\ndependencies:\n- import: a\n  tag: v1\n",
+			deps:     Dependencies{{Import: "a", Tag: "v1"}},
+			private:  []*PrivateRule{{Pattern: "git.corp", Remote: "git@git.corp:{path}.git"}},
+			want:     "version: \"2.0\"\ndependencies:\n- import: a\n  tag: v1\nprivate:\n  - pattern: git.corp\n    remote: git@git.corp:{path}.git\n",
+		},
+		{
+			name:     "duplicate imports",
+			original: "version: \"2.0\"\ndependencies:\n- import: a\n  tag: v1\n- import: a\n  tag: v2\n",
+			deps:     Dependencies{{Import: "a", Tag: "v1"}, {Import: "a", Tag: "v2"}},
+			wantErr:  true,
+		},
+	} {
+		got, err := editConfig([]byte(test.original), &Config{
+			Version:      CurrentVersion,
+			Dependencies: test.deps,
+			Ignore:       test.ignore,
+			Private:      test.private,
+			Insecure:     test.insecure,
+			Clone:        test.clone,
+		})
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: got error %v, want error %v", test.name, err, test.wantErr)
+			continue
+		}
+		if test.want == "" {
+			if got != nil {
+				t.Errorf("%s: got:\n%s\nwant nil", test.name, got)
+			}
+		} else if string(got) != test.want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package repo
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const lockfileExclusiveLock = 0x2
+
+var (
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = kernel32.NewProc("LockFileEx")
+	procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+// lockFile waits for an exclusive lock on the file.
+func lockFile(file *os.File) error {
+	var overlapped syscall.Overlapped
+	if result, _, err := procLockFileEx.Call(file.Fd(), lockfileExclusiveLock, 0, 1, 0, uintptr(unsafe.Pointer(&overlapped))); result == 0 {
+		return err
+	}
+	return nil
+}
+
+// unlockFile releases the lock on the file.
+func unlockFile(file *os.File) error {
+	var overlapped syscall.Overlapped
+	if result, _, err := procUnlockFileEx.Call(file.Fd(), 0, 1, 0, uintptr(unsafe.Pointer(&overlapped))); result == 0 {
+		return err
+	}
+	return nil
+}
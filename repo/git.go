@@ -0,0 +1,450 @@
+package repo
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/richardwilkes/gopathdep/util"
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+const remoteBranchPrefix = "refs/remotes/origin/"
+
+var (
+	hexRegex            = regexp.MustCompile(`^[0-9a-f]{4,64}$`)
+	defaultBranches     = make(map[string]*defaultBranchLookup)
+	defaultBranchesLock sync.Mutex
+)
+
+// defaultBranchLookup holds the result of asking a remote for its default
+// branch, so that each remote is only asked once.
+type defaultBranchLookup struct {
+	once   sync.Once
+	branch string
+	err    error
+}
+
+// Git provides support for git repos.
+var Git VCS = &gitVCS{}
+
+type gitVCS struct {
+}
+
+func (g *gitVCS) Name() string {
+	return "git"
+}
+
+func (g *gitVCS) Dir() string {
+	return ".git"
+}
+
+func (g *gitVCS) Clone(repo *Repo, remote, branchOrTag string) error {
+	root := repo.Root()
+	args := make([]string, 0, 12)
+	args = append(args, "clone", "--quiet")
+	if branchOrTag != "" {
+		args = append(args, "--branch", branchOrTag)
+	}
+	if opts := repo.Options; opts != nil {
+		if opts.Depth > 0 {
+			args = append(args, "--depth", strconv.Itoa(opts.Depth))
+		}
+		if opts.Filter != "" {
+			args = append(args, "--filter", opts.Filter)
+		}
+		if opts.SingleBranch {
+			args = append(args, "--single-branch")
+		}
+	}
+	if UseCache {
+		if mirror, err := refreshMirror(remote); err == nil {
+			args = append(args, "--reference-if-able", mirror, "--dissociate")
+		}
+	}
+	args = append(args, remote, filepath.Base(root))
+	command := exec.Command(g.Name(), args...)
+	command.Dir = filepath.Dir(root)
+	_, err := runWithOutput(command)
+	return err
+}
+
+// Fetch updates the remote-tracking branches. When the cache is in use, the
+// mirror of origin is refreshed and the fetch is made from it instead.
+func (g *gitVCS) Fetch(repo *Repo) error {
+	if UseCache {
+		if err := g.fetchFromMirror(repo); err == nil {
+			return nil
+		}
+	}
+	_, err := repo.Exec("fetch", "--quiet")
+	return err
+}
+
+func (g *gitVCS) fetchFromMirror(repo *Repo) error {
+	origin, err := g.Origin(repo)
+	if err != nil {
+		return err
+	}
+	var refspecs string
+	if refspecs, err = repo.Exec("config", "--get-all", "remote.origin.fetch"); err != nil {
+		return err
+	}
+	var mirror string
+	if mirror, err = refreshMirror(origin); err != nil {
+		return err
+	}
+	_, err = repo.Exec("fetch", append([]string{"--quiet", mirror}, strings.Fields(refspecs)...)...)
+	return err
+}
+
+func (g *gitVCS) Checkout(repo *Repo, revision string) error {
+	_, err := repo.Exec("checkout", "--quiet", revision)
+	return err
+}
+
+func (g *gitVCS) Pull(repo *Repo) error {
+	_, err := repo.Exec("pull", "--quiet")
+	return err
+}
+
+// ResolveRevision prefers tags, then the remote's branches, then anything
+// else git understands.
+func (g *gitVCS) ResolveRevision(repo *Repo, revision string) (string, error) {
+	for _, one := range []string{TagPrefix + revision, remoteBranchPrefix + revision, revision} {
+		if commit, err := repo.Exec("rev-parse", "--quiet", "--verify", one+"^{commit}"); err == nil {
+			return commit, nil
+		}
+	}
+	return "", errs.New(fmt.Sprintf("unable to resolve %s in %s", revision, repo.ImportPath))
+}
+
+// UpdateBranch fast-forwards or resets the branch to its upstream branch as
+// of the last fetch.
+func (g *gitVCS) UpdateBranch(repo *Repo, policy UpdatePolicy) error {
+	branch, err := g.CurrentBranch(repo)
+	if err != nil {
+		return err
+	}
+	if _, err = repo.Exec("rev-parse", "--quiet", "--verify", "@{upstream}"); err != nil {
+		return errs.New(fmt.Sprintf("branch %s of %s has no upstream branch", branch, repo.ImportPath))
+	}
+	var ahead, behind int
+	if ahead, behind, err = g.Divergence(repo); err != nil {
+		return err
+	}
+	if policy == ResetToRemote {
+		if ahead == 0 && behind == 0 {
+			return nil
+		}
+		var dirty bool
+		if dirty, err = g.Dirty(repo); err != nil || dirty {
+			return errs.New(fmt.Sprintf("%s has local modifications that resetting branch %s would discard", repo.ImportPath, branch))
+		}
+		_, err = repo.Exec("reset", "--hard", "--quiet", "@{upstream}")
+		return err
+	}
+	if ahead > 0 && behind > 0 {
+		return &DivergedError{ImportPath: repo.ImportPath, Branch: branch, Ahead: ahead, Behind: behind}
+	}
+	if behind == 0 {
+		return nil
+	}
+	_, err = repo.Exec("merge", "--ff-only", "--quiet", "@{upstream}")
+	return err
+}
+
+func (g *gitVCS) Revision(repo *Repo) (string, error) {
+	return repo.Exec("rev-parse", "HEAD")
+}
+
+// FetchRevision retrieves the revision from origin if it isn't present, which
+// may be the case for shallow or single-branch clones. If fetching the
+// revision directly isn't enough, the rest of the remote's history is
+// fetched.
+func (g *gitVCS) FetchRevision(repo *Repo, revision string) error {
+	if g.hasRevision(repo, revision) {
+		return nil
+	}
+	// Tags and branches are tried first, as their names may look like
+	// abbreviated commits.
+	if _, err := repo.Exec("fetch", "--quiet", "origin", "+"+TagPrefix+revision+":"+TagPrefix+revision); err != nil {
+		if _, err = repo.Exec("fetch", "--quiet", "origin", "+"+BranchPrefix+revision+":"+remoteBranchPrefix+revision); err == nil {
+			// Track the branch, so that it can be checked out and pulled
+			_, err = repo.Exec("remote", "set-branches", "--add", "origin", revision)
+		}
+		if err != nil && hexRegex.MatchString(revision) {
+			_, err = repo.Exec("fetch", "--quiet", "origin", revision)
+		}
+		if err != nil {
+			util.Ignore()
+		}
+	}
+	if g.hasRevision(repo, revision) {
+		return nil
+	}
+	if g.isNarrow(repo) {
+		if _, err := repo.Exec("remote", "set-branches", "origin", "*"); err != nil {
+			return err
+		}
+	}
+	args := []string{"--quiet", "--tags"}
+	if g.isShallow(repo) {
+		args = append(args, "--unshallow")
+	}
+	if _, err := repo.Exec("fetch", append(args, "origin")...); err != nil {
+		return err
+	}
+	if !g.hasRevision(repo, revision) {
+		return errs.New(fmt.Sprintf("unable to find %s in %s", revision, repo.ImportPath))
+	}
+	return nil
+}
+
+func (g *gitVCS) hasRevision(repo *Repo, revision string) bool {
+	for _, one := range []string{revision, remoteBranchPrefix + revision} {
+		if _, err := repo.Exec("rev-parse", "--quiet", "--verify", one+"^{commit}"); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *gitVCS) isShallow(repo *Repo) bool {
+	result, err := repo.Exec("rev-parse", "--is-shallow-repository")
+	return err == nil && result == "true"
+}
+
+// isNarrow returns true if the clone only tracks some of the remote's
+// branches.
+func (g *gitVCS) isNarrow(repo *Repo) bool {
+	result, err := repo.Exec("config", "--get-all", "remote.origin.fetch")
+	return err == nil && result != "+"+BranchPrefix+"*:"+remoteBranchPrefix+"*"
+}
+
+func (g *gitVCS) Tags(repo *Repo, revision string) ([]string, error) {
+	if revision == "" && !Offline && (g.isShallow(repo) || g.isNarrow(repo)) {
+		// The clone may be missing tags, so ask the remote
+		return g.remoteTags(repo)
+	}
+	args := []string{`--format=%(refname)`}
+	if revision != "" {
+		args = append(args, "--points-at", revision)
+	}
+	refs, err := g.refs(repo, append(args, TagPrefix)...)
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]string, 0, len(refs))
+	for _, one := range refs {
+		tags = append(tags, strings.TrimPrefix(one, TagPrefix))
+	}
+	return tags, nil
+}
+
+// remoteTags returns the tags that origin has, rather than those that have
+// been fetched.
+func (g *gitVCS) remoteTags(repo *Repo) ([]string, error) {
+	result, err := repo.Exec("ls-remote", "--quiet", "--tags", "--refs", "origin")
+	if err != nil {
+		return nil, err
+	}
+	var tags []string
+	for _, line := range strings.Split(result, "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && strings.HasPrefix(fields[1], TagPrefix) {
+			tags = append(tags, strings.TrimPrefix(fields[1], TagPrefix))
+		}
+	}
+	return tags, nil
+}
+
+func (g *gitVCS) Branches(repo *Repo, revision string) ([]string, error) {
+	refs, err := g.refs(repo, "--points-at", revision, `--format=%(refname)`, BranchPrefix)
+	if err != nil {
+		return nil, err
+	}
+	var branches []string
+	for _, one := range refs {
+		branch := strings.TrimPrefix(one, BranchPrefix)
+		if remote, remoteErr := repo.Exec("rev-parse", remoteBranchPrefix+branch); remoteErr == nil && remote == revision {
+			branches = append(branches, branch)
+		}
+	}
+	return branches, nil
+}
+
+func (g *gitVCS) refs(repo *Repo, args ...string) ([]string, error) {
+	result, err := repo.Exec("for-each-ref", args...)
+	if err != nil {
+		return nil, err
+	}
+	var refs []string
+	for _, one := range strings.Split(result, "\n") {
+		if one = strings.TrimSpace(one); one != "" {
+			refs = append(refs, one)
+		}
+	}
+	return refs, nil
+}
+
+func (g *gitVCS) Dirty(repo *Repo) (bool, error) {
+	result, err := repo.Exec("status", "--porcelain")
+	if err != nil {
+		return true, err
+	}
+	for _, line := range strings.Split(result, "\n") {
+		if line != "" && !strings.HasPrefix(line, "?? ") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (g *gitVCS) CurrentBranch(repo *Repo) (string, error) {
+	branch, err := repo.Exec("symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		return "", errs.New(fmt.Sprintf("%s is not on a branch", repo.ImportPath))
+	}
+	return branch, nil
+}
+
+func (g *gitVCS) Divergence(repo *Repo) (ahead, behind int, err error) {
+	if _, err = repo.Exec("rev-parse", "--quiet", "--verify", "@{upstream}"); err != nil {
+		return 0, 0, nil
+	}
+	var result string
+	if result, err = repo.Exec("rev-list", "--left-right", "--count", "HEAD...@{upstream}"); err != nil {
+		return 0, 0, err
+	}
+	fields := strings.Fields(result)
+	if len(fields) != 2 {
+		return 0, 0, errs.New(fmt.Sprintf("unexpected output from git rev-list: %s", result))
+	}
+	if ahead, err = strconv.Atoi(fields[0]); err == nil {
+		behind, err = strconv.Atoi(fields[1])
+	}
+	return ahead, behind, errs.Wrap(err)
+}
+
+func (g *gitVCS) Untracked(repo *Repo) (bool, error) {
+	result, err := repo.Exec("status", "--porcelain", "--untracked-files=normal")
+	if err != nil {
+		return false, err
+	}
+	for _, line := range strings.Split(result, "\n") {
+		if strings.HasPrefix(line, "?? ") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Stash pushes a stash entry. As git succeeds without creating one when there
+// is nothing to stash, refs/stash is compared before and after to be sure an
+// entry was actually created.
+func (g *gitVCS) Stash(repo *Repo, includeUntracked bool, message string) (string, error) {
+	before, err := repo.Exec("rev-parse", "--quiet", "--verify", "refs/stash")
+	if err != nil {
+		before = ""
+	}
+	args := []string{"push", "--quiet", "--message", message}
+	if includeUntracked {
+		args = append(args, "--include-untracked")
+	}
+	if _, err = repo.Exec("stash", args...); err != nil {
+		return "", err
+	}
+	var after string
+	if after, err = repo.Exec("rev-parse", "--quiet", "--verify", "refs/stash"); err != nil || after == before {
+		return "", errs.New(fmt.Sprintf("there were no changes to stash in %s", repo.ImportPath))
+	}
+	return after, nil
+}
+
+// Unstash pops the stash entry with the reference. If the changes can't be
+// applied cleanly, the entry is left in place.
+func (g *gitVCS) Unstash(repo *Repo, ref string) error {
+	result, err := repo.Exec("stash", "list", "--format=%H")
+	if err != nil {
+		return err
+	}
+	for i, one := range strings.Split(result, "\n") {
+		if one == ref {
+			_, err = repo.Exec("stash", "pop", "--quiet", fmt.Sprintf("stash@{%d}", i))
+			return err
+		}
+	}
+	return errs.New(fmt.Sprintf("stash %s no longer exists in %s", ref, repo.ImportPath))
+}
+
+func (g *gitVCS) Origin(repo *Repo) (string, error) {
+	return repo.Exec("config", "--get", "remote.origin.url")
+}
+
+// DefaultBranch uses refs/remotes/origin/HEAD, which is set when cloning. If
+// it is missing, it is set from the remote, so that later calls needn't ask
+// again. Without a checkout, the remote is asked directly. Either way, each
+// remote is only asked once per run.
+func (g *gitVCS) DefaultBranch(repo *Repo) (string, error) {
+	root := repo.Root()
+	exists := util.IsDir(root)
+	if exists {
+		if result, err := repo.Exec("symbolic-ref", "--quiet", remoteBranchPrefix+"HEAD"); err == nil {
+			return strings.TrimPrefix(result, remoteBranchPrefix), nil
+		}
+	}
+	if Offline {
+		return "", errs.New(fmt.Sprintf("unable to determine the default branch of %s while offline", repo.ImportPath))
+	}
+	key := root
+	if !exists {
+		key = repo.Remote()
+	}
+	defaultBranchesLock.Lock()
+	lookup, found := defaultBranches[key]
+	if !found {
+		lookup = &defaultBranchLookup{}
+		defaultBranches[key] = lookup
+	}
+	defaultBranchesLock.Unlock()
+	lookup.once.Do(func() {
+		if exists {
+			lookup.branch, lookup.err = g.setDefaultBranch(repo)
+		} else {
+			lookup.branch, lookup.err = g.remoteDefaultBranch(repo)
+		}
+	})
+	return lookup.branch, lookup.err
+}
+
+// setDefaultBranch sets refs/remotes/origin/HEAD from the remote and returns
+// the branch it points to.
+func (g *gitVCS) setDefaultBranch(repo *Repo) (string, error) {
+	if _, err := repo.Exec("remote", "set-head", "origin", "--auto"); err != nil {
+		return "", errs.NewWithCause(fmt.Sprintf("unable to determine the default branch of %s", repo.ImportPath), err)
+	}
+	result, err := repo.Exec("symbolic-ref", "--quiet", remoteBranchPrefix+"HEAD")
+	if err != nil {
+		return "", errs.NewWithCause(fmt.Sprintf("unable to determine the default branch of %s", repo.ImportPath), err)
+	}
+	return strings.TrimPrefix(result, remoteBranchPrefix), nil
+}
+
+// remoteDefaultBranch asks the remote of a repo that hasn't been cloned yet
+// for the branch its HEAD points to.
+func (g *gitVCS) remoteDefaultBranch(repo *Repo) (string, error) {
+	result, err := runWithOutput(exec.Command(g.Name(), "ls-remote", "--symref", repo.Remote(), "HEAD"))
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(result, "\n") {
+		if fields := strings.Fields(line); len(fields) == 3 && fields[0] == "ref:" && fields[2] == "HEAD" {
+			return strings.TrimPrefix(fields[1], BranchPrefix), nil
+		}
+	}
+	return "", errs.New(fmt.Sprintf("unable to determine the default branch of %s", repo.ImportPath))
+}
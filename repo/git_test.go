@@ -0,0 +1,139 @@
+package repo
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+// newGitOrigin creates a repo with a single commit on its main branch and
+// clones it into $GOPATH as example.com/dep.
+func newGitOrigin(t *testing.T) (origin string, r *Repo) {
+	t.Helper()
+	setGitIdentity(t)
+	useTempGoPath(t)
+	origin = t.TempDir()
+	runGit(t, origin, "init", "--quiet", "--initial-branch", "main")
+	commitFile(t, origin, "a.go", "package dep\n")
+	r = &Repo{ImportPath: "example.com/dep", VCS: Git, RemoteURL: origin}
+	if err := r.Clone(""); err != nil {
+		t.Fatal(err)
+	}
+	return origin, r
+}
+
+func TestGitUpdateBranch(t *testing.T) {
+	origin, r := newGitOrigin(t)
+	upstream := commitFile(t, origin, "b.go", "package dep\n\nconst B = 1\n")
+	local := commitFile(t, r.Root(), "c.go", "package dep\n\nconst C = 1\n")
+	if state := r.State(); state.Commit != local || state.Ahead != 1 || state.Behind != 1 {
+		t.Errorf("got state %+v, want 1 commit ahead and 1 behind at %s", state, local)
+	}
+	diverged, err := r.Divergence()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (DivergedError{ImportPath: "example.com/dep", Branch: "main", Ahead: 1, Behind: 1}); diverged == nil || *diverged != want {
+		t.Fatalf("got %v, want %v", diverged, want)
+	}
+	err = r.UpdateBranch(FastForwardOnly)
+	var divergedErr *DivergedError
+	if !errors.As(err, &divergedErr) {
+		t.Fatalf("expected a DivergedError with the %s policy, got %v", FastForwardOnly, err)
+	}
+	var commit string
+	if commit, err = r.Commit(); err != nil || commit != local {
+		t.Errorf("got commit %s (%v) after refusing to update, want %s", commit, err, local)
+	}
+	if err = r.UpdateBranch(ResetToRemote); err != nil {
+		t.Fatal(err)
+	}
+	if commit, err = r.Commit(); err != nil || commit != upstream {
+		t.Errorf("got commit %s (%v) after reset, want %s", commit, err, upstream)
+	}
+	if diverged, err = r.Divergence(); err != nil || diverged != nil {
+		t.Errorf("got %v (%v) after reset, want no divergence", diverged, err)
+	}
+	if branches := runGit(t, r.Root(), "branch", "--contains", local); branches != "" {
+		t.Errorf("local commit %s is still on %s after reset", local, branches)
+	}
+}
+
+func TestGitUpdateBranchFastForward(t *testing.T) {
+	origin, r := newGitOrigin(t)
+	upstream := commitFile(t, origin, "b.go", "package dep\n\nconst B = 1\n")
+	if err := r.Fetch(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.UpdateBranch(FastForwardOnly); err != nil {
+		t.Fatal(err)
+	}
+	if commit, err := r.Commit(); err != nil || commit != upstream {
+		t.Errorf("got commit %s (%v), want %s", commit, err, upstream)
+	}
+}
+
+func TestGitCheckoutHexNames(t *testing.T) {
+	origin, r := newGitOrigin(t)
+	tagged := commitFile(t, origin, "b.go", "package dep\n\nconst B = 1\n")
+	runGit(t, origin, "tag", "deadbeef")
+	runGit(t, origin, "checkout", "--quiet", "-b", "cafe")
+	branched := commitFile(t, origin, "c.go", "package dep\n\nconst C = 1\n")
+	runGit(t, origin, "checkout", "--quiet", "-b", "other", "main")
+	unadvertised := commitFile(t, origin, "d.go", "package dep\n\nconst D = 1\n")
+	runGit(t, origin, "checkout", "--quiet", "main")
+	for _, test := range []struct {
+		revision string
+		want     string
+	}{
+		{revision: "deadbeef", want: tagged},
+		{revision: "cafe", want: branched},
+		{revision: unadvertised, want: unadvertised},
+	} {
+		if err := r.Checkout(test.revision); err != nil {
+			t.Errorf("%s: %v", test.revision, err)
+			continue
+		}
+		if commit, err := r.Commit(); err != nil || commit != test.want {
+			t.Errorf("%s: got commit %s (%v), want %s", test.revision, commit, err, test.want)
+		}
+		if commit, err := r.ResolveRevision(test.revision); err != nil || commit != test.want {
+			t.Errorf("%s: resolved to %s (%v), want %s", test.revision, commit, err, test.want)
+		}
+	}
+}
+
+func TestGitDefaultBranch(t *testing.T) {
+	setGitIdentity(t)
+	useTempGoPath(t)
+	origin := t.TempDir()
+	runGit(t, origin, "init", "--quiet", "--initial-branch", "develop")
+	commitFile(t, origin, "a.go", "package dep\n")
+	uncloned := &Repo{ImportPath: "example.com/uncloned", VCS: Git, RemoteURL: origin}
+	if branch, err := uncloned.DefaultBranch(); err != nil || branch != "develop" {
+		t.Errorf("got %q (%v) from the remote, want develop", branch, err)
+	}
+	r := &Repo{ImportPath: "example.com/dep", VCS: Git, RemoteURL: origin}
+	if err := r.Clone(""); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, r.Root(), "remote", "set-head", "origin", "--delete")
+	// Checking the state must not ask the remote for its default branch
+	if state := r.State(); !state.Exists {
+		t.Fatalf("got state %+v, want it to exist", state)
+	}
+	if out, err := exec.Command("git", "-C", r.Root(), "symbolic-ref", "--quiet", remoteBranchPrefix+"HEAD").Output(); err == nil {
+		t.Errorf("got origin/HEAD %q after checking the state, want it left unset", out)
+	}
+	if branch, err := r.DefaultBranch(); err != nil || branch != "develop" {
+		t.Errorf("got %q (%v) without origin/HEAD, want develop", branch, err)
+	}
+	if head := runGit(t, r.Root(), "symbolic-ref", remoteBranchPrefix+"HEAD"); head != remoteBranchPrefix+"develop" {
+		t.Errorf("got origin/HEAD %q, want it set to develop", head)
+	}
+	for _, vcs := range []VCS{Subversion, Bazaar, Proxy} {
+		if branch, err := vcs.DefaultBranch(r); err != nil || branch != "" {
+			t.Errorf("%s: got %q (%v), want no default branch", vcs.Name(), branch, err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package repo
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Mercurial provides support for Mercurial repos.
+var Mercurial VCS = &hgVCS{}
+
+type hgVCS struct {
+}
+
+func (h *hgVCS) Name() string {
+	return "hg"
+}
+
+func (h *hgVCS) Dir() string {
+	return ".hg"
+}
+
+func (h *hgVCS) Clone(repo *Repo, remote, branchOrTag string) error {
+	root := repo.Root()
+	args := make([]string, 0, 6)
+	args = append(args, "clone", "--quiet")
+	if branchOrTag != "" {
+		args = append(args, "--updaterev", branchOrTag)
+	}
+	args = append(args, remote, filepath.Base(root))
+	command := exec.Command(h.Name(), args...)
+	command.Dir = filepath.Dir(root)
+	_, err := runWithOutput(command)
+	return err
+}
+
+func (h *hgVCS) Fetch(repo *Repo) error {
+	_, err := repo.Exec("pull", "--quiet")
+	return err
+}
+
+func (h *hgVCS) Checkout(repo *Repo, revision string) error {
+	_, err := repo.Exec("update", "--quiet", "--rev", revision)
+	return err
+}
+
+func (h *hgVCS) Pull(repo *Repo) error {
+	_, err := repo.Exec("pull", "--quiet", "--update")
+	return err
+}
+
+func (h *hgVCS) Revision(repo *Repo) (string, error) {
+	return repo.Exec("log", "--rev", ".", "--template", "{node}")
+}
+
+// ResolveRevision relies on a branch name resolving to the tip of the branch.
+func (h *hgVCS) ResolveRevision(repo *Repo, revision string) (string, error) {
+	return repo.Exec("log", "--rev", revision, "--template", "{node}")
+}
+
+func (h *hgVCS) Tags(repo *Repo, revision string) ([]string, error) {
+	var result string
+	var err error
+	if revision == "" {
+		result, err = repo.Exec("tags", "--quiet")
+	} else {
+		result, err = repo.Exec("log", "--rev", revision, "--template", `{join(tags, "\n")}`)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var tags []string
+	for _, one := range strings.Split(result, "\n") {
+		if one = strings.TrimSpace(one); one != "" && one != "tip" {
+			tags = append(tags, one)
+		}
+	}
+	return tags, nil
+}
+
+// Branches returns the named branch of the revision if the revision is that
+// branch's tip, along with any bookmarks that point at it.
+func (h *hgVCS) Branches(repo *Repo, revision string) ([]string, error) {
+	result, err := repo.Exec("log", "--rev", revision, "--template", `{branch}\n{join(bookmarks, "\n")}`)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(result, "\n")
+	var branches []string
+	var tip string
+	if tip, err = repo.Exec("log", "--rev", fmt.Sprintf("max(branch(%s))", revision), "--template", "{node}"); err == nil && tip == revision {
+		branches = append(branches, strings.TrimSpace(lines[0]))
+	}
+	for _, one := range lines[1:] {
+		if one = strings.TrimSpace(one); one != "" {
+			branches = append(branches, one)
+		}
+	}
+	return branches, nil
+}
+
+func (h *hgVCS) Dirty(repo *Repo) (bool, error) {
+	result, err := repo.Exec("status", "--quiet")
+	if err != nil {
+		return true, err
+	}
+	return result != "", nil
+}
+
+func (h *hgVCS) Origin(repo *Repo) (string, error) {
+	return repo.Exec("paths", "default")
+}
+
+func (h *hgVCS) DefaultBranch(repo *Repo) (string, error) {
+	return "default", nil
+}
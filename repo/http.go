@@ -0,0 +1,243 @@
+package repo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+const (
+	maxRedirects = 10
+	// httpTimeout limits the time taken by a request, including reading the
+	// body, so that a stalled server can't hang a command.
+	httpTimeout = 5 * time.Minute
+)
+
+var (
+	httpClient = &http.Client{
+		Transport: &netrcTransport{
+			base: &http.Transport{
+				Proxy:                 http.ProxyFromEnvironment,
+				DialContext:           (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+				TLSHandshakeTimeout:   10 * time.Second,
+				ResponseHeaderTimeout: 30 * time.Second,
+				IdleConnTimeout:       90 * time.Second,
+			},
+		},
+		CheckRedirect: checkRedirect,
+		Timeout:       httpTimeout,
+	}
+	envInsecure     []string
+	configInsecure  []string
+	insecureEnvOnce sync.Once
+	insecureFetches = make(map[string]bool)
+	insecureLock    sync.Mutex
+	netrcOnce       sync.Once
+	netrcMachines   map[string]*netrcMachine
+	netrcDefault    *netrcMachine
+)
+
+type netrcMachine struct {
+	login    string
+	password string
+}
+
+// netrcTransport adds credentials from the user's .netrc file to requests
+// that don't already carry any.
+type netrcTransport struct {
+	base http.RoundTripper
+}
+
+// SetInsecurePatterns sets the GOINSECURE-style glob patterns from the
+// configuration, which identify the import paths that may be fetched over
+// plain http when https fails. Any patterns set by a previously loaded
+// configuration are replaced, while those in $GOINSECURE are always included.
+func SetInsecurePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return errs.New(fmt.Sprintf("invalid insecure pattern '%s'", pattern))
+		}
+	}
+	insecureLock.Lock()
+	configInsecure = patterns
+	insecureLock.Unlock()
+	return nil
+}
+
+// IsInsecure returns true if the import path, or one of its parents, matches
+// an insecure pattern.
+func IsInsecure(importPath string) bool {
+	insecureEnvOnce.Do(func() {
+		var patterns []string
+		for _, one := range strings.Split(os.Getenv("GOINSECURE"), ",") {
+			if one = strings.TrimSpace(one); one != "" {
+				patterns = append(patterns, one)
+			}
+		}
+		insecureLock.Lock()
+		envInsecure = patterns
+		insecureLock.Unlock()
+	})
+	insecureLock.Lock()
+	defer insecureLock.Unlock()
+	for _, patterns := range [][]string{envInsecure, configInsecure} {
+		for _, pattern := range patterns {
+			if matchesPathPattern(pattern, importPath) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// InsecureFetches returns a sorted list of the URLs that were retrieved, or
+// the remotes that were cloned, without transport security.
+func InsecureFetches() []string {
+	insecureLock.Lock()
+	defer insecureLock.Unlock()
+	list := make([]string, 0, len(insecureFetches))
+	for one := range insecureFetches {
+		list = append(list, one)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func noteInsecureFetch(location string) {
+	insecureLock.Lock()
+	insecureFetches[location] = true
+	insecureLock.Unlock()
+}
+
+// isInsecureURL returns true if the URL uses a scheme that provides no
+// transport security.
+func isInsecureURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "git://") || strings.HasPrefix(location, "svn://") || strings.HasPrefix(location, "bzr://")
+}
+
+// checkRedirect limits the number of redirects and refuses redirects to plain
+// http, unless the destination matches an insecure pattern or the original
+// request was already made over plain http to the same host. Redirects to a
+// host that matches a private rule are also refused, unless the original
+// request was made to one, so that a public server can't steer requests, and
+// any credentials, towards an internal host.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return errs.New(fmt.Sprintf("stopped after %d redirects", maxRedirects))
+	}
+	original := via[0].URL
+	target := req.URL.Hostname() + req.URL.Path
+	if req.URL.Scheme != "https" {
+		if !IsInsecure(target) && (original.Scheme == "https" || original.Hostname() != req.URL.Hostname()) {
+			return errs.New(fmt.Sprintf("refusing to follow redirect from %s to insecure %s", via[len(via)-1].URL, req.URL))
+		}
+		noteInsecureFetch(req.URL.String())
+	}
+	if PrivateRuleFor(target) != nil && PrivateRuleFor(original.Hostname()+original.Path) == nil {
+		return errs.New(fmt.Sprintf("refusing to follow redirect from %s to private %s", via[len(via)-1].URL, req.URL))
+	}
+	return nil
+}
+
+func httpGet(location string) (*http.Response, error) {
+	if isInsecureURL(location) {
+		noteInsecureFetch(location)
+	}
+	resp, err := httpClient.Get(location)
+	return resp, errs.Wrap(err)
+}
+
+func (t *netrcTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if _, _, ok := req.BasicAuth(); !ok && req.Header.Get("Authorization") == "" {
+		if machine := netrcFor(req.URL); machine != nil {
+			req = req.Clone(req.Context())
+			req.SetBasicAuth(machine.login, machine.password)
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// netrcFor returns the credentials from the user's .netrc file for the URL's
+// host. Credentials are only sent over plain http to hosts that match an
+// insecure pattern.
+func netrcFor(u *url.URL) *netrcMachine {
+	if u.Scheme != "https" && !IsInsecure(u.Host+u.Path) {
+		return nil
+	}
+	netrcOnce.Do(loadNetrc)
+	if machine, exists := netrcMachines[u.Hostname()]; exists {
+		return machine
+	}
+	return netrcDefault
+}
+
+func loadNetrc() {
+	netrcMachines = make(map[string]*netrcMachine)
+	file := os.Getenv("NETRC")
+	if file == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return
+		}
+		name := ".netrc"
+		if runtime.GOOS == "windows" {
+			name = "_netrc"
+		}
+		file = filepath.Join(home, name)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return
+	}
+	var current *netrcMachine
+	fields := strings.Fields(string(data))
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "machine":
+			if i+1 < len(fields) {
+				i++
+				current = &netrcMachine{}
+				if _, exists := netrcMachines[fields[i]]; !exists {
+					netrcMachines[fields[i]] = current
+				}
+			}
+		case "default":
+			current = &netrcMachine{}
+			if netrcDefault == nil {
+				netrcDefault = current
+			}
+		case "login":
+			if i+1 < len(fields) {
+				i++
+				if current != nil {
+					current.login = fields[i]
+				}
+			}
+		case "password":
+			if i+1 < len(fields) {
+				i++
+				if current != nil {
+					current.password = fields[i]
+				}
+			}
+		case "account":
+			i++
+		case "macdef":
+			// Macros run until a blank line, which Fields can't see, so stop
+			// parsing rather than misreading the macro's contents.
+			return
+		}
+	}
+}
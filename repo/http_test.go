@@ -0,0 +1,108 @@
+package repo
+
+import (
+	"net/http"
+	"testing"
+)
+
+// useRules replaces the insecure patterns and private rules, ignoring those
+// from the environment, for the duration of the test.
+func useRules(t *testing.T, insecure []string, private []*PrivateRule) {
+	t.Helper()
+	IsInsecure("")
+	PrivateRuleFor("")
+	insecureLock.Lock()
+	savedEnvInsecure, savedInsecure := envInsecure, configInsecure
+	envInsecure = nil
+	insecureLock.Unlock()
+	privateRulesLock.Lock()
+	savedEnvPrivate, savedEnvErr, savedPrivate := envPrivateRules, envPrivateErr, configPrivateRules
+	envPrivateRules, envPrivateErr = nil, nil
+	privateRulesLock.Unlock()
+	t.Cleanup(func() {
+		insecureLock.Lock()
+		envInsecure, configInsecure = savedEnvInsecure, savedInsecure
+		insecureLock.Unlock()
+		privateRulesLock.Lock()
+		envPrivateRules, envPrivateErr, configPrivateRules = savedEnvPrivate, savedEnvErr, savedPrivate
+		privateRulesLock.Unlock()
+	})
+	if err := SetInsecurePatterns(insecure); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetPrivateRules(private); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckRedirect(t *testing.T) {
+	useRules(t, []string{"insecure.example.com"}, []*PrivateRule{{Pattern: "git.corp"}})
+	for _, test := range []struct {
+		from    string
+		to      string
+		hops    int
+		wantErr bool
+	}{
+		{from: "https://example.com/a", to: "https://example.com/b"},
+		{from: "https://example.com/a", to: "https://other.example.com/a"},
+		{from: "https://example.com/a", to: "http://example.com/a", wantErr: true},
+		{from: "https://example.com/a", to: "http://insecure.example.com/a"},
+		{from: "http://example.com/a", to: "http://example.com/b"},
+		{from: "http://example.com/a", to: "http://other.example.com/a", wantErr: true},
+		{from: "http://insecure.example.com/a", to: "http://insecure.example.com:8080/a"},
+		{from: "https://example.com/a", to: "https://git.corp/a", wantErr: true},
+		{from: "https://git.corp/a", to: "https://git.corp/b"},
+		{from: "https://git.corp/a", to: "https://example.com/a"},
+		{from: "https://example.com/a", to: "https://example.com/b", hops: maxRedirects, wantErr: true},
+	} {
+		hops := test.hops
+		if hops == 0 {
+			hops = 1
+		}
+		via := make([]*http.Request, 0, hops)
+		for i := 0; i < hops; i++ {
+			prev, err := http.NewRequest(http.MethodGet, test.from, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			via = append(via, prev)
+		}
+		req, err := http.NewRequest(http.MethodGet, test.to, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = checkRedirect(req, via); (err != nil) != test.wantErr {
+			t.Errorf("%s -> %s: got %v, want error %v", test.from, test.to, err, test.wantErr)
+		}
+	}
+}
+
+func TestNetrcFor(t *testing.T) {
+	useRules(t, []string{"insecure.example.com"}, nil)
+	netrcOnce.Do(func() {})
+	savedMachines, savedDefault := netrcMachines, netrcDefault
+	netrcMachines = map[string]*netrcMachine{"example.com": {login: "user", password: "secret"}, "insecure.example.com": {login: "other"}}
+	netrcDefault = nil
+	t.Cleanup(func() { netrcMachines, netrcDefault = savedMachines, savedDefault })
+	for _, test := range []struct {
+		location string
+		want     string
+	}{
+		{location: "https://example.com/a", want: "user"},
+		{location: "http://example.com/a", want: ""},
+		{location: "http://insecure.example.com/a", want: "other"},
+		{location: "https://unknown.example.com/a", want: ""},
+	} {
+		req, err := http.NewRequest(http.MethodGet, test.location, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		if machine := netrcFor(req.URL); machine != nil {
+			got = machine.login
+		}
+		if got != test.want {
+			t.Errorf("%s: got login %q, want %q", test.location, got, test.want)
+		}
+	}
+}
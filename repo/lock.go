@@ -0,0 +1,125 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+const (
+	// CurrentLockVersion of the lock file.
+	CurrentLockVersion = "1.0"
+	// LockFileName is the lock file name.
+	LockFileName = "pathdep.lock"
+)
+
+// Lock holds the exact commits that each dependency resolved to.
+type Lock struct {
+	Dir          string `yaml:"-"`
+	Version      string
+	Dependencies LockedDependencies
+}
+
+// NewLockFromDir creates a new lock from the lock file in the directory. A
+// missing lock file is not an error and results in an empty lock.
+func NewLockFromDir(dir string) (*Lock, error) {
+	lock := &Lock{Dir: dir}
+	data, err := ioutil.ReadFile(filepath.Join(dir, LockFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return lock, nil
+		}
+		return lock, errs.Wrap(err)
+	}
+	return lock, errs.Wrap(yaml.Unmarshal(data, lock))
+}
+
+// Find returns the locked entry for the dependency, or nil if there isn't
+// one or it was resolved from a different tag, branch or version.
+func (lock *Lock) Find(dep *Dependency) *LockedDependency {
+	if lock != nil {
+		for _, one := range lock.Dependencies {
+			if one.Import == dep.Import {
+				if one.Matches(dep) {
+					return one
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// Resolve returns a dependency pinned to the locked commit, or the original
+// dependency if it isn't locked.
+func (lock *Lock) Resolve(dep *Dependency) *Dependency {
+	if locked := lock.Find(dep); locked != nil && locked.Commit != "" {
+		return &Dependency{Import: dep.Import, Commit: locked.Commit, Remote: dep.Remote, VCS: dep.VCS}
+	}
+	return dep
+}
+
+// Set records the commit the dependency resolved to. Any checksum recorded
+// for the same commit is kept.
+func (lock *Lock) Set(dep *Dependency, commit string) {
+	locked := &LockedDependency{
+		Import:  dep.Import,
+		Commit:  commit,
+		Tag:     dep.Tag,
+		Branch:  dep.Branch,
+		Version: dep.Version,
+	}
+	for i, one := range lock.Dependencies {
+		if one.Import == dep.Import {
+			if one.Commit == commit {
+				locked.Hash = one.Hash
+			}
+			lock.Dependencies[i] = locked
+			return
+		}
+	}
+	lock.Dependencies = append(lock.Dependencies, locked)
+}
+
+// SetHash records the checksum of the dependency's files. The dependency's
+// commit must already have been set.
+func (lock *Lock) SetHash(dep *Dependency, hash string) {
+	for _, one := range lock.Dependencies {
+		if one.Import == dep.Import {
+			one.Hash = hash
+			return
+		}
+	}
+}
+
+// Retain removes any locked entries that are no longer present in, or no
+// longer match, the dependencies.
+func (lock *Lock) Retain(deps Dependencies) {
+	set := make(map[string]*Dependency, len(deps))
+	for _, dep := range deps {
+		set[dep.Import] = dep
+	}
+	kept := make(LockedDependencies, 0, len(lock.Dependencies))
+	for _, one := range lock.Dependencies {
+		if dep, exists := set[one.Import]; exists && one.Matches(dep) {
+			kept = append(kept, one)
+		}
+	}
+	lock.Dependencies = kept
+}
+
+// Save the lock file.
+func (lock *Lock) Save() error {
+	lock.Version = CurrentLockVersion
+	sort.Sort(lock.Dependencies)
+	data, err := marshalYAML(lock)
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(lock.Dir, LockFileName), data, 0644)
+	}
+	return errs.Wrap(err)
+}
@@ -0,0 +1,137 @@
+package repo
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestLockFind(t *testing.T) {
+	lock := &Lock{Dependencies: LockedDependencies{
+		{Import: "example.com/tag", Commit: "1111", Tag: "v1.0.0"},
+		{Import: "example.com/branch", Commit: "2222", Branch: "main"},
+		{Import: "example.com/version", Commit: "3333", Version: "^1.2"},
+		{Import: "example.com/default", Commit: "4444"},
+	}}
+	for _, test := range []struct {
+		dep  Dependency
+		want string
+	}{
+		{dep: Dependency{Import: "example.com/tag", Tag: "v1.0.0"}, want: "1111"},
+		{dep: Dependency{Import: "example.com/tag", Tag: "v1.1.0"}},
+		{dep: Dependency{Import: "example.com/branch", Branch: "main"}, want: "2222"},
+		{dep: Dependency{Import: "example.com/branch", Branch: "develop"}},
+		{dep: Dependency{Import: "example.com/version", Version: "^1.2"}, want: "3333"},
+		{dep: Dependency{Import: "example.com/version", Version: "^1.3"}},
+		{dep: Dependency{Import: "example.com/default"}, want: "4444"},
+		{dep: Dependency{Import: "example.com/default", Branch: "main"}},
+		{dep: Dependency{Import: "example.com/missing"}},
+	} {
+		var got string
+		if locked := lock.Find(&test.dep); locked != nil {
+			got = locked.Commit
+		}
+		if got != test.want {
+			t.Errorf("%+v: got %q, want %q", test.dep, got, test.want)
+		}
+	}
+	var nilLock *Lock
+	if nilLock.Find(&Dependency{Import: "example.com/tag"}) != nil {
+		t.Error("a nil lock should find nothing")
+	}
+}
+
+func TestLockResolve(t *testing.T) {
+	lock := &Lock{Dependencies: LockedDependencies{{Import: "example.com/a", Commit: "1111", Branch: "main"}}}
+	dep := &Dependency{Import: "example.com/a", Branch: "main", Remote: "https://mirror.example.com/a", VCS: "git"}
+	want := Dependency{Import: "example.com/a", Commit: "1111", Remote: "https://mirror.example.com/a", VCS: "git"}
+	if got := lock.Resolve(dep); *got != want {
+		t.Errorf("got %+v, want %+v", *got, want)
+	}
+	unlocked := &Dependency{Import: "example.com/b", Tag: "v1"}
+	if got := lock.Resolve(unlocked); got != unlocked {
+		t.Errorf("got %+v, want the dependency itself", *got)
+	}
+}
+
+func TestLockSet(t *testing.T) {
+	lock := &Lock{}
+	dep := &Dependency{Import: "example.com/a", Branch: "main"}
+	lock.Set(dep, "1111")
+	lock.SetHash(dep, "h1:abc")
+	for _, test := range []struct {
+		commit   string
+		wantHash string
+	}{
+		{commit: "1111", wantHash: "h1:abc"},
+		{commit: "2222", wantHash: ""},
+	} {
+		lock.Set(dep, test.commit)
+		locked := lock.Find(dep)
+		if locked == nil || locked.Commit != test.commit || locked.Hash != test.wantHash {
+			t.Errorf("%s: got %+v, want hash %q", test.commit, locked, test.wantHash)
+		}
+	}
+	if len(lock.Dependencies) != 1 {
+		t.Errorf("got %d entries, want 1", len(lock.Dependencies))
+	}
+}
+
+func TestLockRetain(t *testing.T) {
+	lock := &Lock{Dependencies: LockedDependencies{
+		{Import: "example.com/kept", Commit: "1111", Tag: "v1"},
+		{Import: "example.com/changed", Commit: "2222", Tag: "v1"},
+		{Import: "example.com/removed", Commit: "3333"},
+	}}
+	lock.Retain(Dependencies{
+		{Import: "example.com/kept", Tag: "v1"},
+		{Import: "example.com/changed", Tag: "v2"},
+	})
+	if len(lock.Dependencies) != 1 || lock.Dependencies[0].Import != "example.com/kept" {
+		t.Errorf("got %+v, want only example.com/kept", lock.Dependencies)
+	}
+}
+
+func TestLockSaveAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	lock, err := NewLockFromDir(dir)
+	if err != nil || len(lock.Dependencies) != 0 {
+		t.Fatalf("got %+v (%v) for a missing lock file, want an empty lock", lock, err)
+	}
+	lock.Set(&Dependency{Import: "example.com/b", Tag: "v1"}, "2222")
+	lock.Set(&Dependency{Import: "example.com/a", Branch: "main"}, "1111")
+	lock.SetHash(&Dependency{Import: "example.com/a"}, "h1:abc")
+	if err = lock.Save(); err != nil {
+		t.Fatal(err)
+	}
+	var data []byte
+	if data, err = ioutil.ReadFile(filepath.Join(dir, LockFileName)); err != nil {
+		t.Fatal(err)
+	}
+	want := `version: "1.0"
+dependencies:
+  - import: example.com/a
+    commit: "1111"
+    branch: main
+    hash: h1:abc
+  - import: example.com/b
+    commit: "2222"
+    tag: v1
+`
+	if string(data) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", data, want)
+	}
+	var loaded *Lock
+	if loaded, err = NewLockFromDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Dependencies) != 2 || *loaded.Dependencies[0] != *lock.Dependencies[0] || *loaded.Dependencies[1] != *lock.Dependencies[1] {
+		t.Errorf("got %+v after loading, want %+v", loaded.Dependencies, lock.Dependencies)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, LockFileName), []byte("dependencies: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewLockFromDir(dir); err == nil {
+		t.Error("expected an error for a malformed lock file")
+	}
+}
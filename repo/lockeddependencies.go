@@ -0,0 +1,16 @@
+package repo
+
+// LockedDependencies provides a sortable slice of locked dependencies.
+type LockedDependencies []*LockedDependency
+
+func (d LockedDependencies) Len() int {
+	return len(d)
+}
+
+func (d LockedDependencies) Less(i, j int) bool {
+	return d[i].Import < d[j].Import
+}
+
+func (d LockedDependencies) Swap(i, j int) {
+	d[i], d[j] = d[j], d[i]
+}
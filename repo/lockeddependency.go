@@ -0,0 +1,19 @@
+package repo
+
+// LockedDependency holds the commit a dependency resolved to, along with the
+// tag, branch or version it was resolved from. For dependencies downloaded
+// from a module proxy, the checksum of the files is also held.
+type LockedDependency struct {
+	Import  string
+	Commit  string
+	Tag     string `json:",omitempty" yaml:",omitempty"`
+	Branch  string `json:",omitempty" yaml:",omitempty"`
+	Version string `json:",omitempty" yaml:",omitempty"`
+	Hash    string `json:",omitempty" yaml:",omitempty"` // Checksum of the files, for a VCS without history.
+}
+
+// Matches returns true if this locked entry was resolved from the same
+// commit, tag, branch and version as the dependency.
+func (locked *LockedDependency) Matches(dep *Dependency) bool {
+	return locked.Import == dep.Import && (dep.Commit == "" || dep.Commit == locked.Commit) && locked.Tag == dep.Tag && locked.Branch == dep.Branch && locked.Version == dep.Version
+}
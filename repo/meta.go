@@ -0,0 +1,126 @@
+package repo
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+// SourceInfo holds the locations given by a go-source meta tag, which are
+// used to link to a repo's source. The directory and file locations are
+// templates containing {dir}, {/dir}, {file} and {line} placeholders.
+type SourceInfo struct {
+	Home      string
+	Directory string
+	File      string
+}
+
+type metaImport struct {
+	prefix string
+	vcs    string
+	url    string
+}
+
+type metaSource struct {
+	prefix string
+	source SourceInfo
+}
+
+// parseMetaTags parses the go-import and go-source meta tags from the head
+// of an HTML document, in the same way that 'go get' does.
+func parseMetaTags(r io.Reader) ([]metaImport, []metaSource, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charsetReader
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+	var imports []metaImport
+	var sources []metaSource
+	for {
+		token, err := decoder.RawToken()
+		if err != nil {
+			if err != io.EOF && len(imports) == 0 {
+				return nil, nil, errs.Wrap(err)
+			}
+			break
+		}
+		if e, ok := token.(xml.StartElement); ok && strings.EqualFold(e.Name.Local, "body") {
+			break
+		}
+		if e, ok := token.(xml.EndElement); ok && strings.EqualFold(e.Name.Local, "head") {
+			break
+		}
+		e, ok := token.(xml.StartElement)
+		if !ok || !strings.EqualFold(e.Name.Local, "meta") {
+			continue
+		}
+		fields := strings.Fields(attrValue(e.Attr, "content"))
+		switch attrValue(e.Attr, "name") {
+		case "go-import":
+			// A fourth field, giving a subdirectory, may follow
+			if len(fields) == 3 || len(fields) == 4 {
+				imports = append(imports, metaImport{prefix: fields[0], vcs: fields[1], url: fields[2]})
+			}
+		case "go-source":
+			if len(fields) == 4 {
+				sources = append(sources, metaSource{prefix: fields[0], source: SourceInfo{Home: fields[1], Directory: fields[2], File: fields[3]}})
+			}
+		}
+	}
+	return imports, sources, nil
+}
+
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "ascii":
+		return input, nil
+	default:
+		return nil, errs.New(fmt.Sprintf("unable to decode a document using charset '%s'", charset))
+	}
+}
+
+func attrValue(attrs []xml.Attr, name string) string {
+	for _, attr := range attrs {
+		if strings.EqualFold(attr.Name.Local, name) {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// matchMetaImport returns the go-import entry whose prefix is the package or
+// one of its parents. Entries for a supported VCS are preferred over those
+// for a module proxy. It is an error for more than one entry of the same
+// kind to match.
+func matchMetaImport(imports []metaImport, pkg string) (*metaImport, error) {
+	var match *metaImport
+	for i := range imports {
+		one := &imports[i]
+		if VCSByName(one.vcs) == nil || !hasPathPrefix(pkg, one.prefix) {
+			continue
+		}
+		if match != nil {
+			if match.vcs == Proxy.Name() && one.vcs != Proxy.Name() {
+				match = one
+				continue
+			}
+			if match.vcs != Proxy.Name() && one.vcs == Proxy.Name() {
+				continue
+			}
+			return nil, errs.New(fmt.Sprintf("multiple go-import meta tags match %s", pkg))
+		}
+		match = one
+	}
+	if match == nil {
+		return nil, errs.New(fmt.Sprintf("no go-import meta tag matches %s", pkg))
+	}
+	return match, nil
+}
+
+// hasPathPrefix returns true if the path is the prefix or is within it.
+func hasPathPrefix(p, prefix string) bool {
+	return p == prefix || strings.HasPrefix(p, strings.TrimSuffix(prefix, "/")+"/")
+}
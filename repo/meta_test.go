@@ -0,0 +1,114 @@
+package repo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMetaTags(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		html    string
+		imports []metaImport
+		sources []metaSource
+		wantErr bool
+	}{
+		{
+			name:    "import",
+			html:    `<html><head><meta name="go-import" content="example.com/a git https://example.com/a.git"></head></html>`,
+			imports: []metaImport{{prefix: "example.com/a", vcs: "git", url: "https://example.com/a.git"}},
+		},
+		{
+			name:    "subdirectory field",
+			html:    `<meta name="go-import" content="example.com/a git https://example.com/a.git sub">`,
+			imports: []metaImport{{prefix: "example.com/a", vcs: "git", url: "https://example.com/a.git"}},
+		},
+		{
+			name:    "source",
+			html:    `<head><meta name="go-import" content="example.com/a hg https://example.com/a"><meta name="go-source" content="example.com/a https://example.com/a https://example.com/a/tree{/dir} https://example.com/a/blob{/dir}/{file}#L{line}"></head>`,
+			imports: []metaImport{{prefix: "example.com/a", vcs: "hg", url: "https://example.com/a"}},
+			sources: []metaSource{{prefix: "example.com/a", source: SourceInfo{Home: "https://example.com/a", Directory: "https://example.com/a/tree{/dir}", File: "https://example.com/a/blob{/dir}/{file}#L{line}"}}},
+		},
+		{
+			name:    "malformed content",
+			html:    `<meta name="go-import" content="example.com/a git"><meta name="go-source" content="example.com/a https://example.com/a">`,
+			imports: nil,
+		},
+		{
+			name: "stops at body",
+			html: `<head></head><body><meta name="go-import" content="example.com/a git https://example.com/a.git"></body>`,
+		},
+		{
+			name:    "unquoted and uppercase",
+			html:    `<HTML><HEAD><META NAME=go-import CONTENT="example.com/a git https://example.com/a.git"><br></HEAD></HTML>`,
+			imports: []metaImport{{prefix: "example.com/a", vcs: "git", url: "https://example.com/a.git"}},
+		},
+		{
+			name:    "unsupported charset",
+			html:    `<?xml version="1.0" encoding="ISO-8859-1"?><meta name="go-import" content="example.com/a git https://example.com/a.git">`,
+			wantErr: true,
+		},
+	} {
+		imports, sources, err := parseMetaTags(strings.NewReader(test.html))
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: unexpected error state: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(imports, test.imports) {
+			t.Errorf("%s: got imports %+v, want %+v", test.name, imports, test.imports)
+		}
+		if !reflect.DeepEqual(sources, test.sources) {
+			t.Errorf("%s: got sources %+v, want %+v", test.name, sources, test.sources)
+		}
+	}
+}
+
+func TestMatchMetaImport(t *testing.T) {
+	git := metaImport{prefix: "example.com/a", vcs: "git", url: "https://example.com/a.git"}
+	proxy := metaImport{prefix: "example.com/a", vcs: "mod", url: "https://proxy.example.com"}
+	other := metaImport{prefix: "example.com/b", vcs: "git", url: "https://example.com/b.git"}
+	unknown := metaImport{prefix: "example.com/a", vcs: "cvs", url: "https://example.com/a"}
+	for _, test := range []struct {
+		name    string
+		imports []metaImport
+		pkg     string
+		want    *metaImport
+	}{
+		{name: "exact", imports: []metaImport{git, other}, pkg: "example.com/a", want: &git},
+		{name: "parent", imports: []metaImport{other, git}, pkg: "example.com/a/pkg", want: &git},
+		{name: "not a path prefix", imports: []metaImport{git}, pkg: "example.com/abc"},
+		{name: "vcs preferred over proxy", imports: []metaImport{proxy, git}, pkg: "example.com/a", want: &git},
+		{name: "proxy alone", imports: []metaImport{proxy}, pkg: "example.com/a", want: &proxy},
+		{name: "unknown vcs", imports: []metaImport{unknown}, pkg: "example.com/a"},
+		{name: "ambiguous", imports: []metaImport{git, {prefix: "example.com/a", vcs: "hg", url: "https://example.com/a"}}, pkg: "example.com/a"},
+	} {
+		got, err := matchMetaImport(test.imports, test.pkg)
+		switch {
+		case test.want == nil && err == nil:
+			t.Errorf("%s: got %+v, want an error", test.name, *got)
+		case test.want != nil && err != nil:
+			t.Errorf("%s: %v", test.name, err)
+		case test.want != nil && *got != *test.want:
+			t.Errorf("%s: got %+v, want %+v", test.name, *got, *test.want)
+		}
+	}
+}
+
+func TestHasPathPrefix(t *testing.T) {
+	for _, test := range []struct {
+		path   string
+		prefix string
+		want   bool
+	}{
+		{path: "example.com/a", prefix: "example.com/a", want: true},
+		{path: "example.com/a/b", prefix: "example.com/a", want: true},
+		{path: "example.com/a/b", prefix: "example.com/a/", want: true},
+		{path: "example.com/ab", prefix: "example.com/a", want: false},
+		{path: "example.com", prefix: "example.com/a", want: false},
+	} {
+		if got := hasPathPrefix(test.path, test.prefix); got != test.want {
+			t.Errorf("%s in %s: got %v, want %v", test.path, test.prefix, got, test.want)
+		}
+	}
+}
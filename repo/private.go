@@ -0,0 +1,162 @@
+package repo
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+// PrivateEnvVar is the environment variable that holds private rules, as a
+// comma-separated list of patterns, each optionally followed by '=' and a
+// remote URL template.
+const PrivateEnvVar = "GOPATHDEP_PRIVATE"
+
+// PrivateRule identifies import paths whose remote can't be discovered with
+// an HTTP request, such as those on an internal host. The pattern is a glob,
+// as used by GOPRIVATE, that matches leading elements of the import path.
+// The remote is a URL template, in which {import} is replaced by the repo's
+// root import path, {host} by its first element and {path} by the rest. If
+// no remote is given, "https://{import}" is used.
+type PrivateRule struct {
+	Pattern string
+	Remote  string `yaml:",omitempty"`
+	VCS     string `yaml:",omitempty"`
+}
+
+var (
+	envPrivateRules    []*PrivateRule
+	envPrivateErr      error
+	configPrivateRules []*PrivateRule
+	privateRulesLock   sync.Mutex
+	privateEnvOnce     sync.Once
+)
+
+// SetPrivateRules sets the rules from the configuration that are consulted
+// when looking up remotes, replacing any set by a previously loaded
+// configuration. The rules from $GOPATHDEP_PRIVATE are consulted first, and
+// an error is returned if any of them are invalid.
+func SetPrivateRules(rules []*PrivateRule) error {
+	if err := loadEnvPrivateRules(); err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+	}
+	privateRulesLock.Lock()
+	configPrivateRules = rules
+	privateRulesLock.Unlock()
+	return nil
+}
+
+// Validate checks the rule for problems.
+func (rule *PrivateRule) Validate() error {
+	if rule.Pattern == "" {
+		return errs.New("private rule is missing a pattern")
+	}
+	if _, err := path.Match(rule.Pattern, ""); err != nil {
+		return errs.NewWithCause(fmt.Sprintf("invalid pattern '%s' in private rule", rule.Pattern), err)
+	}
+	if rule.VCS != "" && VCSByName(rule.VCS) == nil {
+		return errs.New(fmt.Sprintf("unknown vcs '%s' in private rule for '%s'", rule.VCS, rule.Pattern))
+	}
+	remaining := strings.NewReplacer("{import}", "", "{host}", "", "{path}", "").Replace(rule.Remote)
+	if strings.ContainsAny(remaining, "{}") {
+		return errs.New(fmt.Sprintf("unknown placeholder in remote '%s' of private rule for '%s'", rule.Remote, rule.Pattern))
+	}
+	return nil
+}
+
+// Matches returns true if the import path, or one of its parents, matches
+// the rule's pattern.
+func (rule *PrivateRule) Matches(importPath string) bool {
+	return matchesPathPattern(rule.Pattern, importPath)
+}
+
+// matchesPathPattern returns true if the leading elements of the import path
+// match the glob pattern, as with GOPRIVATE.
+func matchesPathPattern(pattern, importPath string) bool {
+	n := strings.Count(pattern, "/") + 1
+	parts := strings.Split(importPath, "/")
+	if len(parts) < n {
+		return false
+	}
+	matched, err := path.Match(pattern, strings.Join(parts[:n], "/"))
+	return err == nil && matched
+}
+
+// RemoteInfo returns the VCS and remote URL for the root import path.
+func (rule *PrivateRule) RemoteInfo(importPath string) *RemoteInfo {
+	host := importPath
+	var rest string
+	if i := strings.IndexByte(importPath, '/'); i != -1 {
+		host = importPath[:i]
+		rest = importPath[i+1:]
+	}
+	template := rule.Remote
+	if template == "" {
+		template = "https://{import}"
+	}
+	info := &RemoteInfo{
+		Root: importPath,
+		VCS:  rule.VCS,
+		URL:  strings.NewReplacer("{import}", importPath, "{host}", host, "{path}", rest).Replace(template),
+	}
+	if info.VCS == "" {
+		info.VCS = Git.Name()
+	}
+	return info
+}
+
+// loadEnvPrivateRules parses the rules in $GOPATHDEP_PRIVATE the first time
+// it is called, returning the problem with the first invalid rule, if any.
+func loadEnvPrivateRules() error {
+	privateEnvOnce.Do(func() {
+		var rules []*PrivateRule
+		var err error
+		for _, one := range strings.Split(os.Getenv(PrivateEnvVar), ",") {
+			if one = strings.TrimSpace(one); one != "" {
+				rule := &PrivateRule{Pattern: one}
+				if i := strings.IndexByte(one, '='); i != -1 {
+					rule.Pattern = one[:i]
+					rule.Remote = one[i+1:]
+				}
+				if err = rule.Validate(); err != nil {
+					err = errs.NewWithCause(fmt.Sprintf("invalid entry '%s' in $%s", one, PrivateEnvVar), err)
+					rules = nil
+					break
+				}
+				rules = append(rules, rule)
+			}
+		}
+		privateRulesLock.Lock()
+		envPrivateRules = rules
+		envPrivateErr = err
+		privateRulesLock.Unlock()
+	})
+	privateRulesLock.Lock()
+	defer privateRulesLock.Unlock()
+	return envPrivateErr
+}
+
+// PrivateRuleFor returns the first private rule that matches the import
+// path, or nil. Rules from an invalid $GOPATHDEP_PRIVATE are not used; the
+// problem is reported when a configuration is loaded.
+func PrivateRuleFor(importPath string) *PrivateRule {
+	loadEnvPrivateRules()
+	privateRulesLock.Lock()
+	defer privateRulesLock.Unlock()
+	for _, rules := range [][]*PrivateRule{envPrivateRules, configPrivateRules} {
+		for _, rule := range rules {
+			if rule.Matches(importPath) {
+				return rule
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package repo
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestPrivateRuleValidate(t *testing.T) {
+	for _, test := range []struct {
+		rule    PrivateRule
+		wantErr bool
+	}{
+		{rule: PrivateRule{Pattern: "git.corp"}},
+		{rule: PrivateRule{Pattern: "git.corp/*", Remote: "git@git.corp:{path}.git", VCS: "git"}},
+		{rule: PrivateRule{Pattern: "*.corp", Remote: "ssh://hg@{host}/{path}", VCS: "hg"}},
+		{rule: PrivateRule{}, wantErr: true},
+		{rule: PrivateRule{Pattern: "git.corp/[a-"}, wantErr: true},
+		{rule: PrivateRule{Pattern: "git.corp", VCS: "cvs"}, wantErr: true},
+		{rule: PrivateRule{Pattern: "git.corp", Remote: "https://{hostname}/{path}"}, wantErr: true},
+	} {
+		if err := test.rule.Validate(); (err != nil) != test.wantErr {
+			t.Errorf("%+v: got %v, want error %v", test.rule, err, test.wantErr)
+		}
+	}
+}
+
+func TestPrivateEnvRules(t *testing.T) {
+	useRules(t, nil, nil)
+	for _, test := range []struct {
+		env     string
+		private string
+		wantErr bool
+	}{
+		{env: "git.corp, *.internal=ssh://git@{host}/{path}", private: "hg.internal/repo"},
+		{env: "git.corp,git.corp/[a-", wantErr: true},
+		{env: "git.corp=https://{hostname}/{path}", wantErr: true},
+	} {
+		t.Setenv(PrivateEnvVar, test.env)
+		privateEnvOnce = sync.Once{}
+		err := SetPrivateRules(nil)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: got %v, want error %v", test.env, err, test.wantErr)
+		}
+		if test.private != "" && PrivateRuleFor(test.private) == nil {
+			t.Errorf("%s: %s should be private", test.env, test.private)
+		}
+		if test.wantErr && PrivateRuleFor("git.corp/repo") != nil {
+			t.Errorf("%s: rules should not be used", test.env)
+		}
+	}
+}
+
+func TestPrivateRuleMatches(t *testing.T) {
+	for _, test := range []struct {
+		pattern    string
+		importPath string
+		want       bool
+	}{
+		{pattern: "git.corp", importPath: "git.corp/team/repo", want: true},
+		{pattern: "git.corp", importPath: "git.corp", want: true},
+		{pattern: "git.corp", importPath: "git.corporate.com/repo", want: false},
+		{pattern: "*.corp", importPath: "hg.corp/repo", want: true},
+		{pattern: "git.corp/team", importPath: "git.corp/team/repo/pkg", want: true},
+		{pattern: "git.corp/team", importPath: "git.corp/other/repo", want: false},
+		{pattern: "git.corp/*/repo", importPath: "git.corp/team/repo", want: true},
+		{pattern: "git.corp/team/repo", importPath: "git.corp/team", want: false},
+	} {
+		rule := &PrivateRule{Pattern: test.pattern}
+		if got := rule.Matches(test.importPath); got != test.want {
+			t.Errorf("%s matching %s: got %v, want %v", test.pattern, test.importPath, got, test.want)
+		}
+	}
+}
+
+func TestPrivateRuleRemoteInfo(t *testing.T) {
+	for _, test := range []struct {
+		rule       PrivateRule
+		importPath string
+		want       RemoteInfo
+	}{
+		{rule: PrivateRule{Pattern: "git.corp"}, importPath: "git.corp/team/repo", want: RemoteInfo{Root: "git.corp/team/repo", VCS: "git", URL: "https://git.corp/team/repo"}},
+		{rule: PrivateRule{Pattern: "git.corp", Remote: "git@git.corp:{path}.git"}, importPath: "git.corp/team/repo", want: RemoteInfo{Root: "git.corp/team/repo", VCS: "git", URL: "git@git.corp:team/repo.git"}},
+		{rule: PrivateRule{Pattern: "*.corp", Remote: "ssh://hg@{host}/{path}", VCS: "hg"}, importPath: "hg.corp/repo", want: RemoteInfo{Root: "hg.corp/repo", VCS: "hg", URL: "ssh://hg@hg.corp/repo"}},
+	} {
+		if got := test.rule.RemoteInfo(test.importPath); got.Root != test.want.Root || got.VCS != test.want.VCS || got.URL != test.want.URL {
+			t.Errorf("%s: got %+v, want %+v", test.importPath, *got, test.want)
+		}
+	}
+}
+
+func TestConfigReplacesRules(t *testing.T) {
+	useRules(t, nil, nil)
+	dir := t.TempDir()
+	for _, test := range []struct {
+		data        string
+		private     string
+		notPrivate  string
+		insecure    string
+		notInsecure string
+	}{
+		{
+			data:        "version: \"2.0\"\nprivate:\n- pattern: one.corp\ninsecure:\n- one.example.com\n",
+			private:     "one.corp/repo",
+			notPrivate:  "two.corp/repo",
+			insecure:    "one.example.com/repo",
+			notInsecure: "two.example.com/repo",
+		},
+		{
+			data:        "version: \"2.0\"\nprivate:\n- pattern: two.corp\ninsecure:\n- two.example.com\n",
+			private:     "two.corp/repo",
+			notPrivate:  "one.corp/repo",
+			insecure:    "two.example.com/repo",
+			notInsecure: "one.example.com/repo",
+		},
+		{
+			data:        "version: \"2.0\"\n",
+			notPrivate:  "two.corp/repo",
+			notInsecure: "two.example.com/repo",
+		},
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, ConfigFileName), []byte(test.data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewConfigFromDir(dir); err != nil {
+			t.Fatal(err)
+		}
+		if test.private != "" && PrivateRuleFor(test.private) == nil {
+			t.Errorf("%s should be private", test.private)
+		}
+		if PrivateRuleFor(test.notPrivate) != nil {
+			t.Errorf("%s should not be private", test.notPrivate)
+		}
+		if test.insecure != "" && !IsInsecure(test.insecure) {
+			t.Errorf("%s should be insecure", test.insecure)
+		}
+		if IsInsecure(test.notInsecure) {
+			t.Errorf("%s should not be insecure", test.notInsecure)
+		}
+	}
+}
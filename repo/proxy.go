@@ -0,0 +1,433 @@
+package repo
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/richardwilkes/gopathdep/util"
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+const (
+	proxyDir      = ".gopathdep"
+	proxyInfoFile = "module.json"
+	// maxProxyResponse is the largest response accepted from a proxy, which
+	// matches the largest module zip that the go command accepts.
+	maxProxyResponse = 500 << 20
+)
+
+// Proxy provides support for dependencies downloaded from a Go module proxy
+// that speaks the GOPROXY protocol, rather than cloned from their repos. The
+// remote URL is the base URL of the proxy. Since there is no history to
+// work with, each checkout downloads and unpacks a fresh copy of the module.
+// The checksum of the module's files is available from ContentHash, so that
+// it can be recorded in the lock file and modifications detected later.
+var Proxy VCS = &proxyVCS{}
+
+type proxyVCS struct {
+}
+
+type proxyInfo struct {
+	Proxy    string
+	Module   string
+	Version  string
+	Query    string
+	Revision string
+}
+
+type proxyVersionInfo struct {
+	Version string
+	Origin  struct {
+		Hash string
+	}
+}
+
+func (p *proxyVCS) Name() string {
+	return "mod"
+}
+
+func (p *proxyVCS) Dir() string {
+	return proxyDir
+}
+
+func (p *proxyVCS) Clone(repo *Repo, remote, branchOrTag string) error {
+	return p.download(repo, remote, branchOrTag)
+}
+
+// Fetch does nothing, as there is no local history to update.
+func (p *proxyVCS) Fetch(repo *Repo) error {
+	return nil
+}
+
+func (p *proxyVCS) Checkout(repo *Repo, revision string) error {
+	info, err := p.info(repo)
+	if err != nil {
+		return err
+	}
+	if revision == info.Query || revision == info.Version || revision == info.Revision {
+		return nil
+	}
+	return p.download(repo, p.remote(repo, info), revision)
+}
+
+// Pull downloads the module again, which picks up any change to the branch
+// it was originally downloaded for.
+func (p *proxyVCS) Pull(repo *Repo) error {
+	info, err := p.info(repo)
+	if err != nil {
+		return err
+	}
+	return p.download(repo, p.remote(repo, info), info.Query)
+}
+
+func (p *proxyVCS) Revision(repo *Repo) (string, error) {
+	info, err := p.info(repo)
+	if err != nil {
+		return "", err
+	}
+	return info.Revision, nil
+}
+
+// Tags returns the versions of the module. Before the module has been
+// downloaded, the versions of the module whose path matches the import path
+// are listed instead, so that a version constraint can be resolved first.
+func (p *proxyVCS) Tags(repo *Repo, revision string) ([]string, error) {
+	info, err := p.info(repo)
+	if err != nil {
+		if revision != "" || repo.RemoteURL == "" || util.IsDir(repo.Root()) {
+			return nil, err
+		}
+		info = &proxyInfo{Module: repo.ImportPath}
+	}
+	if revision != "" {
+		if revision == info.Revision {
+			return info.tags(), nil
+		}
+		return nil, nil
+	}
+	if Offline {
+		return nil, errs.New(fmt.Sprintf("unable to list the versions of %s while offline", repo.ImportPath))
+	}
+	var data []byte
+	if data, err = proxyGet(moduleURL(p.remote(repo, info), info.Module, "@v/list")); err != nil {
+		return nil, err
+	}
+	var tags []string
+	for _, one := range strings.Fields(string(data)) {
+		tags = append(tags, strings.TrimSuffix(one, "+incompatible"))
+	}
+	return tags, nil
+}
+
+// versionTags returns the version of the downloaded module as a tag, unless
+// it is a pseudo-version. Unlike Tags, this works when the proxy didn't
+// report the commit the version was made from.
+func (p *proxyVCS) versionTags(repo *Repo) ([]string, error) {
+	info, err := p.info(repo)
+	if err != nil {
+		return nil, err
+	}
+	return info.tags(), nil
+}
+
+func (info *proxyInfo) tags() []string {
+	if IsPseudoVersion(info.Version) {
+		return nil
+	}
+	return []string{strings.TrimSuffix(info.Version, "+incompatible")}
+}
+
+// Branches returns the branch the module was downloaded for, if any. The
+// branch may have moved on since then.
+func (p *proxyVCS) Branches(repo *Repo, revision string) ([]string, error) {
+	info, err := p.info(repo)
+	if err != nil {
+		return nil, err
+	}
+	if revision == info.Revision && info.Query != "" && info.Query != info.Version && !hexRegex.MatchString(info.Query) {
+		if _, ok := ParseSemVer(info.Query); !ok {
+			return []string{info.Query}, nil
+		}
+	}
+	return nil, nil
+}
+
+// Dirty returns false, as there is nothing local to compare against.
+// Modifications are detected by comparing the ContentHash with the one
+// recorded in the lock file.
+func (p *proxyVCS) Dirty(repo *Repo) (bool, error) {
+	if _, err := p.info(repo); err != nil {
+		return true, err
+	}
+	return false, nil
+}
+
+// ContentHash returns the go.sum-style checksum of the module's files.
+func (p *proxyVCS) ContentHash(repo *Repo) (string, error) {
+	info, err := p.info(repo)
+	if err != nil {
+		return "", err
+	}
+	return hashDir(repo.Root(), info.Module+"@"+info.Version)
+}
+
+// Origin returns an empty string, as a module downloaded from a proxy has no
+// repo to compare against.
+func (p *proxyVCS) Origin(repo *Repo) (string, error) {
+	return "", nil
+}
+
+func (p *proxyVCS) DefaultBranch(repo *Repo) (string, error) {
+	return "", nil
+}
+
+func (p *proxyVCS) remote(repo *Repo, info *proxyInfo) string {
+	if repo.RemoteURL != "" {
+		return repo.RemoteURL
+	}
+	return info.Proxy
+}
+
+func (p *proxyVCS) info(repo *Repo) (*proxyInfo, error) {
+	data, err := ioutil.ReadFile(filepath.Join(repo.Root(), proxyDir, proxyInfoFile))
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	var info proxyInfo
+	if err = json.Unmarshal(data, &info); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return &info, nil
+}
+
+// download the module version identified by the query, which may be a tag,
+// commit or branch, replacing the files in the repo's root. The latest
+// version is downloaded if the query is empty.
+func (p *proxyVCS) download(repo *Repo, proxy, query string) error {
+	if Offline {
+		return errs.New(fmt.Sprintf("unable to download %s while offline", repo.ImportPath))
+	}
+	proxy = strings.TrimSuffix(proxy, "/")
+	info := &proxyInfo{Proxy: proxy, Query: query}
+	var versionInfo proxyVersionInfo
+	var err error
+	for _, candidate := range moduleCandidates(repo.ImportPath, query) {
+		if hexRegex.MatchString(candidate.query) {
+			// A static proxy can't answer queries for commits, so look for a
+			// version that was made from the commit first.
+			if version := versionForRevision(proxy, candidate.module, candidate.query); version != "" {
+				candidate.query = version
+			}
+		}
+		endpoint := "@latest"
+		if candidate.query != "" {
+			endpoint = "@v/" + escapeModulePath(candidate.query) + ".info"
+		}
+		var data []byte
+		if data, err = proxyGet(moduleURL(proxy, candidate.module, endpoint)); err == nil {
+			if err = json.Unmarshal(data, &versionInfo); err == nil {
+				info.Module = candidate.module
+				break
+			}
+		}
+	}
+	if err != nil {
+		return errs.NewWithCause(fmt.Sprintf("unable to find %s of %s at %s", describeQuery(query), repo.ImportPath, proxy), err)
+	}
+	info.Version = versionInfo.Version
+	switch {
+	case versionInfo.Origin.Hash != "":
+		info.Revision = versionInfo.Origin.Hash
+	case hexRegex.MatchString(query) && len(query) >= 40:
+		info.Revision = query
+	case IsPseudoVersion(info.Version):
+		info.Revision = PseudoVersionRevision(info.Version)
+	}
+	var data []byte
+	if data, err = proxyGet(moduleURL(proxy, info.Module, "@v/"+escapeModulePath(info.Version)+".zip")); err != nil {
+		return err
+	}
+	root := repo.Root()
+	tmp := root + ".download"
+	if err = os.RemoveAll(tmp); err == nil {
+		if err = unzipModule(data, info.Module+"@"+info.Version, tmp); err == nil {
+			if data, err = json.MarshalIndent(info, "", "  "); err == nil {
+				if err = os.MkdirAll(filepath.Join(tmp, proxyDir), 0777); err == nil {
+					if err = ioutil.WriteFile(filepath.Join(tmp, proxyDir, proxyInfoFile), data, 0644); err == nil {
+						err = replaceDir(root, tmp)
+					}
+				}
+			}
+		}
+	}
+	if err != nil {
+		if removeErr := os.RemoveAll(tmp); removeErr != nil {
+			util.Ignore()
+		}
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+// versionForRevision returns the listed version of the module whose origin
+// is the commit, or an empty string if there isn't one.
+func versionForRevision(proxy, module, revision string) string {
+	data, err := proxyGet(moduleURL(proxy, module, "@v/list"))
+	if err != nil {
+		return ""
+	}
+	for _, version := range strings.Fields(string(data)) {
+		if data, err = proxyGet(moduleURL(proxy, module, "@v/"+escapeModulePath(version)+".info")); err == nil {
+			var versionInfo proxyVersionInfo
+			if err = json.Unmarshal(data, &versionInfo); err == nil && versionInfo.Origin.Hash != "" && strings.HasPrefix(versionInfo.Origin.Hash, revision) {
+				return versionInfo.Version
+			}
+		}
+	}
+	return ""
+}
+
+type moduleCandidate struct {
+	module string
+	query  string
+}
+
+// moduleCandidates returns the module paths and queries to try for the
+// import path. A version with a major version of 2 or more may belong to
+// either a module with a major version suffix, or to an older repo without a
+// go.mod, which proxies serve as an "+incompatible" version.
+func moduleCandidates(importPath, query string) []moduleCandidate {
+	candidates := []moduleCandidate{{module: importPath, query: query}}
+	if v, ok := ParseSemVer(query); ok && v.Major > 1 && !strings.HasPrefix(importPath, "gopkg.in/") {
+		suffix := fmt.Sprintf("/v%d", v.Major)
+		if !strings.HasSuffix(importPath, suffix) {
+			candidates = []moduleCandidate{{module: importPath + suffix, query: query}, {module: importPath, query: query + "+incompatible"}}
+		}
+	}
+	return candidates
+}
+
+func describeQuery(query string) string {
+	if query == "" {
+		return "the latest version"
+	}
+	return query
+}
+
+// moduleURL returns the URL of an endpoint of the proxy for the module, such
+// as "@v/list" or "@latest".
+func moduleURL(proxy, module, endpoint string) string {
+	return proxy + "/" + escapeModulePath(module) + "/" + endpoint
+}
+
+func proxyGet(url string) ([]byte, error) {
+	resp, err := httpGet(url)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err = resp.Body.Close(); err != nil {
+			util.Ignore()
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errs.New(fmt.Sprintf("%s returned %s", url, resp.Status))
+	}
+	var data []byte
+	if data, err = ioutil.ReadAll(io.LimitReader(resp.Body, maxProxyResponse+1)); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	if len(data) > maxProxyResponse {
+		return nil, errs.New(fmt.Sprintf("%s returned more than %d bytes", url, maxProxyResponse))
+	}
+	return data, nil
+}
+
+// escapeModulePath escapes upper-case letters as required by the GOPROXY
+// protocol, replacing each with an exclamation mark followed by the
+// lower-case letter.
+func escapeModulePath(p string) string {
+	var buffer strings.Builder
+	for _, r := range p {
+		if unicode.IsUpper(r) {
+			buffer.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		buffer.WriteRune(r)
+	}
+	return buffer.String()
+}
+
+// unzipModule unpacks a module zip, whose files all have the prefix
+// "module@version/", into the directory.
+func unzipModule(data []byte, prefix, dir string) error {
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return errs.Wrap(err)
+	}
+	for _, f := range r.File {
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		name := strings.TrimPrefix(f.Name, prefix+"/")
+		if name == f.Name || name == "" || path.IsAbs(name) || path.Clean(name) != name || strings.HasPrefix(name, "../") {
+			return errs.New(fmt.Sprintf("unexpected file %s in module zip for %s", f.Name, prefix))
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(target), 0777); err != nil {
+			return errs.Wrap(err)
+		}
+		if err = extractFile(f, target); err != nil {
+			return err
+		}
+	}
+	return os.MkdirAll(dir, 0777)
+}
+
+func extractFile(f *zip.File, target string) error {
+	in, err := f.Open()
+	if err != nil {
+		return errs.Wrap(err)
+	}
+	defer func() {
+		if err = in.Close(); err != nil {
+			util.Ignore()
+		}
+	}()
+	var out *os.File
+	if out, err = os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644); err != nil {
+		return errs.Wrap(err)
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return errs.Wrap(err)
+}
+
+// replaceDir replaces the directory with the replacement directory.
+func replaceDir(dir, replacement string) error {
+	old := dir + ".old"
+	if err := os.RemoveAll(old); err != nil {
+		return errs.Wrap(err)
+	}
+	if util.IsDir(dir) {
+		if err := os.Rename(dir, old); err != nil {
+			return errs.Wrap(err)
+		}
+	}
+	if err := os.Rename(replacement, dir); err != nil {
+		return errs.Wrap(err)
+	}
+	return errs.Wrap(os.RemoveAll(old))
+}
@@ -0,0 +1,211 @@
+package repo
+
+import (
+	"archive/zip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+const (
+	testModule     = "example.com/mod"
+	testModuleHash = "h1:xU2e3YNI3ZWW6iOg0x3yqZ/m54IfmNzMlbXsyRj+iJI="
+	testCommit     = "0123456789abcdef0123456789abcdef01234567"
+)
+
+func writeModuleZip(t *testing.T, path, prefix string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		var out interface{ Write([]byte) (int, error) }
+		if out, err = w.Create(prefix + "/" + name); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = out.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newStaticProxy serves a module proxy from plain files, which can't answer
+// queries for commits or branches.
+func newStaticProxy(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	base := filepath.Join(dir, testModule, "@v")
+	writeTestFile(t, filepath.Join(base, "list"), "v1.0.0\nv1.1.0\n")
+	writeTestFile(t, filepath.Join(base, "v1.0.0.info"), `{"Version":"v1.0.0","Origin":{"Hash":"`+testCommit+`"}}`)
+	writeTestFile(t, filepath.Join(base, "v1.1.0.info"), `{"Version":"v1.1.0"}`)
+	writeModuleZip(t, filepath.Join(base, "v1.0.0.zip"), testModule+"@v1.0.0", map[string]string{
+		"go.mod": "module example.com/mod\n",
+		"a.go":   "package mod\n",
+	})
+	writeModuleZip(t, filepath.Join(base, "v1.1.0.zip"), testModule+"@v1.1.0", map[string]string{
+		"go.mod": "module example.com/mod\n",
+		"a.go":   "package mod\n\nconst V = 1\n",
+	})
+	server := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestProxyDownload(t *testing.T) {
+	proxy := newStaticProxy(t)
+	for _, test := range []struct {
+		query    string
+		version  string
+		revision string
+	}{
+		{query: "v1.0.0", version: "v1.0.0", revision: testCommit},
+		{query: testCommit, version: "v1.0.0", revision: testCommit},
+		{query: testCommit[:12], version: "v1.0.0", revision: testCommit},
+		{query: "v1.1.0", version: "v1.1.0"},
+	} {
+		src := useTempGoPath(t)
+		r := &Repo{ImportPath: testModule, VCS: Proxy, RemoteURL: proxy}
+		if err := r.Clone(test.query); err != nil {
+			t.Errorf("%q: %v", test.query, err)
+			continue
+		}
+		info, err := Proxy.(*proxyVCS).info(r)
+		if err != nil {
+			t.Errorf("%q: %v", test.query, err)
+			continue
+		}
+		if info.Version != test.version {
+			t.Errorf("%q: got version %s, want %s", test.query, info.Version, test.version)
+		}
+		var revision string
+		if revision, err = r.Commit(); err != nil || revision != test.revision {
+			t.Errorf("%q: got revision %s (%v), want %s", test.query, revision, err, test.revision)
+		}
+		if _, err = os.Stat(filepath.Join(src, testModule, "go.mod")); err != nil {
+			t.Errorf("%q: go.mod was not unpacked: %v", test.query, err)
+		}
+	}
+}
+
+func TestProxyContentHash(t *testing.T) {
+	proxy := newStaticProxy(t)
+	src := useTempGoPath(t)
+	r := &Repo{ImportPath: testModule, VCS: Proxy, RemoteURL: proxy}
+	if err := r.Clone("v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := r.ContentHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != testModuleHash {
+		t.Errorf("got hash %s, want %s", hash, testModuleHash)
+	}
+	if _, err = os.Stat(filepath.Join(src, testModule, proxyDir, proxyInfoFile)); err != nil {
+		t.Errorf("module info was not written: %v", err)
+	}
+	writeTestFile(t, filepath.Join(src, testModule, "a.go"), "package mod\n\n// changed\n")
+	var changed string
+	if changed, err = r.ContentHash(); err != nil {
+		t.Fatal(err)
+	}
+	if changed == hash {
+		t.Error("hash did not change after modifying a file")
+	}
+}
+
+func TestProxyTags(t *testing.T) {
+	proxy := newStaticProxy(t)
+	useTempGoPath(t)
+	r := &Repo{ImportPath: testModule, VCS: Proxy, RemoteURL: proxy}
+	want := []string{"v1.0.0", "v1.1.0"}
+	tags, err := r.Tags()
+	if sort.Strings(tags); err != nil || !reflect.DeepEqual(tags, want) {
+		t.Errorf("got tags %v (%v) before downloading, want %v", tags, err, want)
+	}
+	if err = r.Clone("v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	if tags, err = r.Tags(); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(tags)
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("got tags %v, want %v", tags, want)
+	}
+	if tags, err = Proxy.Tags(r, testCommit); err != nil || !reflect.DeepEqual(tags, []string{"v1.0.0"}) {
+		t.Errorf("got tags %v (%v) at the commit, want [v1.0.0]", tags, err)
+	}
+}
+
+func TestProxyMissingVersion(t *testing.T) {
+	proxy := newStaticProxy(t)
+	useTempGoPath(t)
+	r := &Repo{ImportPath: testModule, VCS: Proxy, RemoteURL: proxy}
+	if err := r.Clone("v9.9.9"); err == nil {
+		t.Error("expected an error for a version the proxy doesn't have")
+	}
+	if err := r.Clone(""); err == nil {
+		t.Error("expected an error for the latest version from a static proxy")
+	}
+}
+
+func TestProxyUnknownRevision(t *testing.T) {
+	proxy := newStaticProxy(t)
+	useTempGoPath(t)
+	r := &Repo{ImportPath: testModule, VCS: Proxy, RemoteURL: proxy}
+	if err := r.Clone("v1.1.0"); err != nil {
+		t.Fatal(err)
+	}
+	state := r.State()
+	if state.Commit != "" {
+		t.Errorf("got commit %q for a version without an origin, want none", state.Commit)
+	}
+	if !reflect.DeepEqual(state.Tags, []string{"v1.1.0"}) {
+		t.Errorf("got tags %v, want [v1.1.0]", state.Tags)
+	}
+}
+
+func TestEscapeModulePath(t *testing.T) {
+	for _, test := range []struct {
+		path string
+		want string
+	}{
+		{path: "example.com/mod", want: "example.com/mod"},
+		{path: "github.com/BurntSushi/toml", want: "github.com/!burnt!sushi/toml"},
+	} {
+		if got := escapeModulePath(test.path); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.path, got, test.want)
+		}
+	}
+}
+
+func TestModuleCandidates(t *testing.T) {
+	for _, test := range []struct {
+		importPath string
+		query      string
+		want       []moduleCandidate
+	}{
+		{importPath: "example.com/mod", query: "v1.2.0", want: []moduleCandidate{{module: "example.com/mod", query: "v1.2.0"}}},
+		{importPath: "example.com/mod", query: "v2.1.0", want: []moduleCandidate{{module: "example.com/mod/v2", query: "v2.1.0"}, {module: "example.com/mod", query: "v2.1.0+incompatible"}}},
+		{importPath: "example.com/mod/v2", query: "v2.1.0", want: []moduleCandidate{{module: "example.com/mod/v2", query: "v2.1.0"}}},
+		{importPath: "gopkg.in/yaml.v3", query: "v3.0.1", want: []moduleCandidate{{module: "gopkg.in/yaml.v3", query: "v3.0.1"}}},
+		{importPath: "example.com/mod", query: "master", want: []moduleCandidate{{module: "example.com/mod", query: "master"}}},
+	} {
+		if got := moduleCandidates(test.importPath, test.query); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s@%s: got %v, want %v", test.importPath, test.query, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package repo
+
+import "regexp"
+
+var pseudoVersionRegex = regexp.MustCompile(`^v[0-9]+\.(0\.0-|\d+\.\d+-([^+]*\.)?0\.)\d{14}-([0-9a-f]{12})(\+incompatible)?$`)
+
+// IsPseudoVersion returns true if the version is a Go module pseudo-version.
+func IsPseudoVersion(version string) bool {
+	return pseudoVersionRegex.MatchString(version)
+}
+
+// PseudoVersionRevision returns the abbreviated commit hash encoded in a
+// pseudo-version.
+func PseudoVersionRevision(version string) string {
+	if m := pseudoVersionRegex.FindStringSubmatch(version); m != nil {
+		return m[3]
+	}
+	return ""
+}
@@ -0,0 +1,27 @@
+package repo
+
+import "testing"
+
+func TestPseudoVersion(t *testing.T) {
+	for _, test := range []struct {
+		version  string
+		revision string
+	}{
+		{version: "v0.0.0-20191109021931-daa7c04131f5", revision: "daa7c04131f5"},
+		{version: "v1.2.4-0.20191109021931-daa7c04131f5", revision: "daa7c04131f5"},
+		{version: "v1.2.3-pre.0.20191109021931-daa7c04131f5", revision: "daa7c04131f5"},
+		{version: "v2.0.0-20191109021931-daa7c04131f5+incompatible", revision: "daa7c04131f5"},
+		{version: "v1.2.3"},
+		{version: "v1.2.3-pre"},
+		{version: "v0.0.0-2019110902193-daa7c04131f5"},
+		{version: "v0.0.0-20191109021931-DAA7C04131F5"},
+		{version: ""},
+	} {
+		if got := IsPseudoVersion(test.version); got != (test.revision != "") {
+			t.Errorf("IsPseudoVersion(%q): got %v", test.version, got)
+		}
+		if got := PseudoVersionRevision(test.version); got != test.revision {
+			t.Errorf("PseudoVersionRevision(%q): got %q, want %q", test.version, got, test.revision)
+		}
+	}
+}
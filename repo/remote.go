@@ -0,0 +1,147 @@
+package repo
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/richardwilkes/gopathdep/util"
+)
+
+// RemoteInfo holds the location of the repo for a package.
+type RemoteInfo struct {
+	Root   string // The import path of the repo's root.
+	VCS    string
+	URL    string
+	Source *SourceInfo // Locations for linking to the repo's source, if known.
+}
+
+type remoteCall struct {
+	done chan struct{}
+	info *RemoteInfo
+}
+
+var (
+	remoteCache     = make(map[string]*RemoteInfo)
+	remoteCalls     = make(map[string]*remoteCall)
+	remoteCacheLock sync.Mutex
+)
+
+// LookupRemote returns the VCS and remote URL for the package. Packages that
+// match a private rule use the rule's remote. Otherwise, the go-import meta
+// tag served for the package is consulted, unless a recent result is present
+// in the on-disk cache or Offline is set. Plain http is only tried for
+// packages that match an insecure pattern. Failing that, the package is
+// assumed to be a git repo served over https. Concurrent lookups of the same
+// package share a single request, while different packages are looked up in
+// parallel.
+func LookupRemote(pkg string) *RemoteInfo {
+	remoteCacheLock.Lock()
+	if info, exists := remoteCache[pkg]; exists {
+		remoteCacheLock.Unlock()
+		return info
+	}
+	if call, exists := remoteCalls[pkg]; exists {
+		remoteCacheLock.Unlock()
+		<-call.done
+		return call.info
+	}
+	call := &remoteCall{done: make(chan struct{})}
+	remoteCalls[pkg] = call
+	remoteCacheLock.Unlock()
+
+	call.info = lookupRemote(pkg)
+
+	remoteCacheLock.Lock()
+	remoteCache[pkg] = call.info
+	delete(remoteCalls, pkg)
+	remoteCacheLock.Unlock()
+	close(call.done)
+	return call.info
+}
+
+func lookupRemote(pkg string) *RemoteInfo {
+	if rule := PrivateRuleFor(pkg); rule != nil {
+		return rule.RemoteInfo(pkg)
+	}
+	info, cached := loadCachedRemote(pkg)
+	if !cached && !Offline {
+		info = scanForGoImport("https", pkg)
+		if info == nil && IsInsecure(pkg) {
+			info = scanForGoImport("http", pkg)
+		}
+		saveCachedRemote(pkg, info)
+	}
+	if info == nil {
+		info = &RemoteInfo{Root: pkg, VCS: Git.Name(), URL: "https://" + pkg}
+	}
+	return info
+}
+
+// Link returns a URL for browsing the repo, which is the home page given by a
+// go-source meta tag, if any, or else the remote URL if it uses https.
+func (info *RemoteInfo) Link() string {
+	if info.Source != nil && info.Source.Home != "" {
+		return info.Source.Home
+	}
+	if strings.HasPrefix(info.URL, "https://") {
+		return strings.TrimSuffix(info.URL, ".git")
+	}
+	return ""
+}
+
+// SameRemote returns true if the two remote URLs refer to the same repo,
+// ignoring any trailing slash or ".git" suffix.
+func SameRemote(url1, url2 string) bool {
+	return normalizeRemote(url1) == normalizeRemote(url2)
+}
+
+func normalizeRemote(url string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(strings.TrimSpace(url), "/"), ".git")
+}
+
+func scanForGoImport(protocol, pkg string) *RemoteInfo {
+	imports, sources, err := fetchMetaTags(protocol, pkg)
+	if err != nil {
+		return nil
+	}
+	var match *metaImport
+	if match, err = matchMetaImport(imports, pkg); err != nil {
+		return nil
+	}
+	if match.prefix != pkg {
+		// Confirm that the root agrees, so that a package can't claim a
+		// different repo for one of its parents.
+		rootImports, _, rootErr := fetchMetaTags(protocol, match.prefix)
+		if rootErr != nil {
+			return nil
+		}
+		var rootMatch *metaImport
+		if rootMatch, rootErr = matchMetaImport(rootImports, match.prefix); rootErr != nil || *rootMatch != *match {
+			return nil
+		}
+	}
+	if isInsecureURL(match.url) && !IsInsecure(pkg) {
+		return nil
+	}
+	info := &RemoteInfo{Root: match.prefix, VCS: match.vcs, URL: match.url}
+	for i := range sources {
+		if sources[i].prefix == match.prefix {
+			info.Source = &sources[i].source
+			break
+		}
+	}
+	return info
+}
+
+func fetchMetaTags(protocol, pkg string) ([]metaImport, []metaSource, error) {
+	resp, err := httpGet(protocol + "://" + pkg + "?go-get=1")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if err = resp.Body.Close(); err != nil {
+			util.Ignore()
+		}
+	}()
+	return parseMetaTags(resp.Body)
+}
@@ -0,0 +1,133 @@
+package repo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/richardwilkes/gopathdep/util"
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+const remoteCacheFile = "remotes.json"
+
+// RemoteCacheTTL is how long the results of go-import lookups are kept in the
+// on-disk cache. Setting it to zero disables the on-disk cache.
+var RemoteCacheTTL = 24 * time.Hour
+
+// FailedRemoteCacheTTL is how long lookups that found no go-import meta tag
+// are remembered, so that hosts that don't serve one aren't asked again on
+// every run, while a host that was briefly unreachable is soon tried again.
+var FailedRemoteCacheTTL = 10 * time.Minute
+
+type cachedRemote struct {
+	Info    *RemoteInfo // nil if the lookup failed.
+	Fetched time.Time
+}
+
+// expired returns true if the entry is too old to be used.
+func (c *cachedRemote) expired(now time.Time) bool {
+	ttl := RemoteCacheTTL
+	if c.Info == nil && FailedRemoteCacheTTL < ttl {
+		ttl = FailedRemoteCacheTTL
+	}
+	return now.Sub(c.Fetched) >= ttl
+}
+
+var (
+	cachedRemotes     map[string]*cachedRemote
+	cachedRemotesOnce sync.Once
+	cachedRemotesLock sync.Mutex
+)
+
+func remoteCachePath() string {
+	return filepath.Join(CacheDir(), remoteCacheFile)
+}
+
+func readCachedRemotes() map[string]*cachedRemote {
+	remotes := make(map[string]*cachedRemote)
+	if data, err := ioutil.ReadFile(remoteCachePath()); err == nil {
+		if err = json.Unmarshal(data, &remotes); err != nil {
+			remotes = make(map[string]*cachedRemote)
+		}
+	}
+	return remotes
+}
+
+// loadCachedRemote returns the result of a previous lookup of the package, if
+// it hasn't expired, and whether one was found. The result is nil if the
+// lookup failed.
+func loadCachedRemote(pkg string) (*RemoteInfo, bool) {
+	if RemoteCacheTTL <= 0 {
+		return nil, false
+	}
+	cachedRemotesOnce.Do(func() {
+		cachedRemotes = readCachedRemotes()
+	})
+	cachedRemotesLock.Lock()
+	defer cachedRemotesLock.Unlock()
+	if one, exists := cachedRemotes[pkg]; exists && !one.expired(time.Now()) {
+		if one.Info == nil {
+			return nil, true
+		}
+		// An insecure remote is only acceptable if the package still
+		// matches an insecure pattern.
+		if !isInsecureURL(one.Info.URL) || IsInsecure(pkg) {
+			return one.Info, true
+		}
+	}
+	return nil, false
+}
+
+// saveCachedRemote records the result of a lookup in the on-disk cache, where
+// a nil result records a failed lookup. The file is re-read first, so that
+// the results of other processes are kept.
+func saveCachedRemote(pkg string, info *RemoteInfo) {
+	if RemoteCacheTTL <= 0 {
+		return
+	}
+	cachedRemotesLock.Lock()
+	defer cachedRemotesLock.Unlock()
+	remotes := readCachedRemotes()
+	now := time.Now()
+	for key, one := range remotes {
+		if one.expired(now) {
+			delete(remotes, key)
+		}
+	}
+	remotes[pkg] = &cachedRemote{Info: info, Fetched: now}
+	if err := writeCachedRemotes(remotes); err != nil {
+		util.Ignore()
+	}
+}
+
+func writeCachedRemotes(remotes map[string]*cachedRemote) error {
+	data, err := json.MarshalIndent(remotes, "", "  ")
+	if err != nil {
+		return errs.Wrap(err)
+	}
+	if err = os.MkdirAll(CacheDir(), 0777); err != nil {
+		return errs.Wrap(err)
+	}
+	path := remoteCachePath()
+	tmp := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+	if err = ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return errs.Wrap(err)
+	}
+	return errs.Wrap(os.Rename(tmp, path))
+}
+
+// ClearRemoteCache removes the on-disk cache of go-import lookups.
+func ClearRemoteCache() error {
+	cachedRemotesLock.Lock()
+	defer cachedRemotesLock.Unlock()
+	cachedRemotes = make(map[string]*cachedRemote)
+	if err := os.Remove(remoteCachePath()); err != nil && !os.IsNotExist(err) {
+		return errs.Wrap(err)
+	}
+	return nil
+}
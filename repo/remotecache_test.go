@@ -0,0 +1,67 @@
+package repo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCachedRemoteExpired(t *testing.T) {
+	now := time.Now()
+	info := &RemoteInfo{Root: "example.com/a", VCS: "git", URL: "https://example.com/a"}
+	for _, test := range []struct {
+		name  string
+		entry cachedRemote
+		want  bool
+	}{
+		{name: "fresh", entry: cachedRemote{Info: info, Fetched: now.Add(-time.Hour)}, want: false},
+		{name: "stale", entry: cachedRemote{Info: info, Fetched: now.Add(-RemoteCacheTTL)}, want: true},
+		{name: "fresh failure", entry: cachedRemote{Fetched: now.Add(-time.Minute)}, want: false},
+		{name: "stale failure", entry: cachedRemote{Fetched: now.Add(-FailedRemoteCacheTTL)}, want: true},
+		{name: "failure within success ttl", entry: cachedRemote{Fetched: now.Add(-time.Hour)}, want: true},
+	} {
+		if got := test.entry.expired(now); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRemoteCacheRoundTrip(t *testing.T) {
+	t.Setenv(CacheEnvVar, t.TempDir())
+	useRules(t, nil, nil)
+	cachedRemotesOnce.Do(func() {})
+	cachedRemotesLock.Lock()
+	saved := cachedRemotes
+	cachedRemotesLock.Unlock()
+	t.Cleanup(func() {
+		cachedRemotesLock.Lock()
+		cachedRemotes = saved
+		cachedRemotesLock.Unlock()
+	})
+	good := &RemoteInfo{Root: "example.com/a", VCS: "git", URL: "https://example.com/a"}
+	insecure := &RemoteInfo{Root: "example.com/c", VCS: "git", URL: "http://example.com/c"}
+	saveCachedRemote("example.com/a", good)
+	saveCachedRemote("example.com/b", nil)
+	saveCachedRemote("example.com/c", insecure)
+	cachedRemotesLock.Lock()
+	cachedRemotes = readCachedRemotes()
+	cachedRemotesLock.Unlock()
+	for _, test := range []struct {
+		pkg        string
+		wantURL    string
+		wantCached bool
+	}{
+		{pkg: "example.com/a", wantURL: good.URL, wantCached: true},
+		{pkg: "example.com/b", wantCached: true},
+		{pkg: "example.com/c", wantCached: false},
+		{pkg: "example.com/d", wantCached: false},
+	} {
+		info, cached := loadCachedRemote(test.pkg)
+		var url string
+		if info != nil {
+			url = info.URL
+		}
+		if cached != test.wantCached || url != test.wantURL {
+			t.Errorf("%s: got %q, %v, want %q, %v", test.pkg, url, cached, test.wantURL, test.wantCached)
+		}
+	}
+}
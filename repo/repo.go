@@ -32,12 +32,18 @@ type request struct {
 	response chan *response
 }
 
-// Repo holds information for the git repo.
+// Repo holds information for the repo.
 type Repo struct {
 	ImportPath string
+	RemoteURL  string        // Overrides the remote URL discovered from the import path, if set.
+	VCS        VCS           // The version control system for the repo. Git is used if not set.
+	Options    *CloneOptions // Options to use when cloning the repo, if any.
 }
 
 var (
+	// Offline prevents any network access when set. Fetching and pulling
+	// become no-ops, remote lookups are not performed and cloning fails.
+	Offline  bool
 	cmdQueue chan *request
 )
 
@@ -49,16 +55,16 @@ func init() {
 // NewFromImportPath creates a new repo from the import path.
 func NewFromImportPath(importPath string, force bool) (*Repo, error) {
 	var err error
-	gitRoot := fromGoPath(importPath)
+	root := fromGoPath(importPath)
 	if !force {
-		gitRoot, err = util.GitRoot(gitRoot)
+		root, err = util.RepoRoot(root)
 	}
 	if err == nil {
-		stripped := util.StripPrefix(gitRoot, util.SrcPaths)
-		if stripped != gitRoot {
-			return &Repo{ImportPath: stripped}, nil
+		stripped := util.StripPrefix(root, util.SrcPaths)
+		if stripped != root {
+			return &Repo{ImportPath: stripped, VCS: VCSForDir(root)}, nil
 		}
-		return nil, errs.New(fmt.Sprintf("%s is outside of $GOPATH %v", gitRoot, util.SrcPaths))
+		return nil, errs.New(fmt.Sprintf("%s is outside of $GOPATH %v", root, util.SrcPaths))
 	}
 	return nil, err
 }
@@ -82,14 +88,22 @@ func (repo *Repo) Root() string {
 	return fromGoPath(repo.ImportPath)
 }
 
-// Exec runs a git command against the repo.
+// System returns the version control system for the repo.
+func (repo *Repo) System() VCS {
+	if repo.VCS == nil {
+		return Git
+	}
+	return repo.VCS
+}
+
+// Exec runs a command for the repo's version control system against the repo.
 func (repo *Repo) Exec(cmd string, arg ...string) (string, error) {
-	command := exec.Command("git", append([]string{cmd}, arg...)...)
+	command := exec.Command(repo.System().Name(), append([]string{cmd}, arg...)...)
 	command.Dir = repo.Root()
-	return repo.runWithOutput(command)
+	return runWithOutput(command)
 }
 
-func (repo *Repo) runWithOutput(cmd *exec.Cmd) (string, error) {
+func runWithOutput(cmd *exec.Cmd) (string, error) {
 	rspCh := make(chan *response, 1)
 	cmdQueue <- &request{
 		cmd:      cmd,
@@ -152,82 +166,187 @@ func (repo *Repo) State() *State {
 	state := &State{Import: repo.ImportPath}
 	if err := repo.Fetch(); err == nil {
 		state.Exists = true
-		if state.Commit, err = repo.Exec("rev-parse", "HEAD"); err == nil {
-			var result string
-			if result, err = repo.Exec("for-each-ref", "--points-at", state.Commit, `--format=%(refname)`); err == nil {
-				for _, one := range strings.Split(result, "\n") {
-					one = strings.TrimSpace(one)
-					if one != "" {
-						if strings.HasPrefix(one, BranchPrefix) {
-							branch := strings.TrimPrefix(one, BranchPrefix)
-							var remote string
-							if remote, err = repo.Exec("rev-parse", "refs/remotes/origin/"+branch); err == nil && remote == state.Commit {
-								state.Branches = append(state.Branches, branch)
-							}
-						} else if strings.HasPrefix(one, TagPrefix) {
-							state.Tags = append(state.Tags, strings.TrimPrefix(one, TagPrefix))
-						}
-					}
-				}
+		vcs := repo.System()
+		if state.Commit, err = repo.Commit(); err == nil {
+			if state.Branches, err = vcs.Branches(repo, state.Commit); err != nil {
+				state.Branches = nil
+			}
+			if state.Commit == "" && vcs == Proxy {
+				// The proxy didn't report the commit the module was made
+				// from, but its version is still known.
+				state.Tags, err = Proxy.(*proxyVCS).versionTags(repo)
+			} else {
+				state.Tags, err = vcs.Tags(repo, state.Commit)
+			}
+			if err == nil {
 				sort.Slice(state.Tags, func(i, j int) bool {
 					return txt.NaturalLess(state.Tags[j], state.Tags[i], true)
 				})
-			}
-
-			if result, err = repo.Exec("status", "--porcelain"); err == nil {
-				state.Dirty = false
-				for _, line := range strings.Split(result, "\n") {
-					if line != "" && !strings.HasPrefix(line, "?? ") {
-						state.Dirty = true
-						break
-					}
-				}
 			} else {
+				state.Tags = nil
+			}
+			var result string
+			if result, err = vcs.Origin(repo); err == nil {
+				state.Origin = result
+			}
+			if state.Dirty, err = vcs.Dirty(repo); err != nil {
 				state.Dirty = true
 			}
+			if hasher, ok := vcs.(ContentHasher); ok {
+				if state.Hash, err = hasher.ContentHash(repo); err != nil {
+					state.Hash = ""
+				}
+			}
+			if tracker, ok := vcs.(UpstreamTracker); ok {
+				if state.Ahead, state.Behind, err = tracker.Divergence(repo); err != nil {
+					state.Ahead = 0
+					state.Behind = 0
+				}
+			}
 		}
 	}
 	return state
 }
 
-// Remote returns the git remote URL for the repo.
+// Remote returns the remote URL for the repo.
 func (repo *Repo) Remote() string {
-	return GitRemote(repo.ImportPath)
+	if repo.RemoteURL != "" {
+		return repo.RemoteURL
+	}
+	return LookupRemote(repo.ImportPath).URL
 }
 
-// Clone runs the git clone command.
+// Clone the repo from its remote. If the repo's VCS hasn't been set, it is
+// taken from the scheme of the remote URL, if set, or else discovered from the
+// import path, in which case the import path is also corrected to the root of
+// the repo if it names a package within it.
 func (repo *Repo) Clone(branchOrTag string) error {
-	root := repo.Root()
-	dir := filepath.Dir(root)
-	err := os.MkdirAll(dir, 0777)
-	if err == nil {
-		args := make([]string, 0, 5)
-		args = append(args, "clone", "--quiet")
-		if branchOrTag != "" {
-			args = append(args, "--branch", branchOrTag)
+	if Offline {
+		return errs.New(fmt.Sprintf("unable to clone %s while offline", repo.ImportPath))
+	}
+	if repo.VCS == nil {
+		if repo.RemoteURL != "" {
+			repo.VCS = VCSForURL(repo.RemoteURL)
+		} else {
+			info := LookupRemote(repo.ImportPath)
+			if info.Root != repo.ImportPath && hasPathPrefix(repo.ImportPath, info.Root) {
+				repo.ImportPath = info.Root
+			}
+			repo.VCS = VCSByName(info.VCS)
 		}
-		args = append(args, repo.Remote(), filepath.Base(root))
-		command := exec.Command("git", args...)
-		command.Dir = dir
-		_, err = repo.runWithOutput(command)
+	}
+	remote := repo.Remote()
+	if isInsecureURL(remote) {
+		noteInsecureFetch(remote)
+	}
+	err := os.MkdirAll(filepath.Dir(repo.Root()), 0777)
+	if err == nil {
+		err = repo.System().Clone(repo, remote, branchOrTag)
 	}
 	return err
 }
 
-// Fetch runs the git fetch command.
+// DefaultBranch returns the branch the remote checks out by default. An empty
+// string is returned if the VCS has no notion of one, as with Subversion and
+// Bazaar, where the remote URL already names a branch.
+func (repo *Repo) DefaultBranch() (string, error) {
+	return repo.System().DefaultBranch(repo)
+}
+
+// Fetch updates the local copy of the remote's history. Does nothing when
+// Offline is set.
 func (repo *Repo) Fetch() error {
-	_, err := repo.Exec("fetch", "--quiet")
-	return err
+	if Offline {
+		return nil
+	}
+	return repo.System().Fetch(repo)
 }
 
-// Pull runs the git pull command.
+// Pull brings the current branch up to date with the remote. Does nothing
+// when Offline is set.
 func (repo *Repo) Pull() error {
-	_, err := repo.Exec("pull", "--quiet")
-	return err
+	if Offline {
+		return nil
+	}
+	return repo.System().Pull(repo)
+}
+
+// ResolveRevision returns the commit that the tag, branch or commit refers
+// to. Branches are resolved to their tips on the remote as of the last fetch.
+// The revision is only fetched if it can't be found locally.
+func (repo *Repo) ResolveRevision(revision string) (string, error) {
+	vcs := repo.System()
+	resolver, ok := vcs.(RevisionResolver)
+	if !ok {
+		return "", errs.New(fmt.Sprintf("unable to resolve %s in %s, as %s doesn't support it", revision, repo.ImportPath, vcs.Name()))
+	}
+	commit, err := resolver.ResolveRevision(repo, revision)
+	if err != nil {
+		if fetcher, isFetcher := vcs.(RevisionFetcher); isFetcher && !Offline {
+			if err = fetcher.FetchRevision(repo, revision); err == nil {
+				commit, err = resolver.ResolveRevision(repo, revision)
+			}
+		}
+	}
+	return commit, err
 }
 
-// Checkout runs the git checkout command.
+// ContentHash returns a checksum of the repo's files if its VCS has no
+// history to detect modifications with, or an empty string otherwise.
+func (repo *Repo) ContentHash() (string, error) {
+	if hasher, ok := repo.System().(ContentHasher); ok {
+		return hasher.ContentHash(repo)
+	}
+	return "", nil
+}
+
+// UpdateBranch brings the checked out branch up to date with the remote,
+// following the policy if the VCS supports it, or else by pulling. The remote's
+// history should already have been fetched.
+func (repo *Repo) UpdateBranch(policy UpdatePolicy) error {
+	if updater, ok := repo.System().(BranchUpdater); ok {
+		return updater.UpdateBranch(repo, policy)
+	}
+	return repo.Pull()
+}
+
+// Divergence returns a DivergedError describing the checked out branch if
+// both it and its upstream branch have commits the other doesn't, or nil if
+// they haven't diverged or the VCS doesn't track upstream branches.
+func (repo *Repo) Divergence() (*DivergedError, error) {
+	tracker, ok := repo.System().(UpstreamTracker)
+	if !ok {
+		return nil, nil
+	}
+	ahead, behind, err := tracker.Divergence(repo)
+	if err != nil || ahead == 0 || behind == 0 {
+		return nil, err
+	}
+	var branch string
+	if branch, err = tracker.CurrentBranch(repo); err != nil {
+		return nil, err
+	}
+	return &DivergedError{ImportPath: repo.ImportPath, Branch: branch, Ahead: ahead, Behind: behind}, nil
+}
+
+// Commit returns the commit currently checked out.
+func (repo *Repo) Commit() (string, error) {
+	return repo.System().Revision(repo)
+}
+
+// Tags returns all of the tags in the repo.
+func (repo *Repo) Tags() ([]string, error) {
+	return repo.System().Tags(repo, "")
+}
+
+// Checkout updates the working copy to the commit, tag or branch. If the VCS
+// is a RevisionFetcher, the revision is first fetched if it isn't present.
 func (repo *Repo) Checkout(commit string) error {
-	_, err := repo.Exec("checkout", "--quiet", commit)
-	return err
+	vcs := repo.System()
+	if fetcher, ok := vcs.(RevisionFetcher); ok && !Offline {
+		if err := fetcher.FetchRevision(repo, commit); err != nil {
+			return err
+		}
+	}
+	return vcs.Checkout(repo, commit)
 }
@@ -0,0 +1,87 @@
+package repo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// useOffline sets Offline for the duration of the test.
+func useOffline(t *testing.T) {
+	t.Helper()
+	Offline = true
+	t.Cleanup(func() { Offline = false })
+}
+
+// newCountingServer returns the URL of a server that fails every request,
+// along with the number of requests it has received.
+func newCountingServer(t *testing.T) (string, *int32) {
+	t.Helper()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.Error(w, "unexpected request", http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, &requests
+}
+
+func TestOfflineClone(t *testing.T) {
+	useTempGoPath(t)
+	t.Setenv(CacheEnvVar, t.TempDir())
+	useOffline(t)
+	remote, requests := newCountingServer(t)
+	for _, r := range []*Repo{
+		{ImportPath: "example.com/dep", VCS: Git, RemoteURL: remote + "/dep.git"},
+		{ImportPath: testModule, VCS: Proxy, RemoteURL: remote},
+	} {
+		if err := r.Clone(""); err == nil || !strings.Contains(err.Error(), "while offline") {
+			t.Errorf("%s: got %v, want an error about being offline", r.System().Name(), err)
+		}
+	}
+	r := &Repo{ImportPath: testModule, VCS: Proxy, RemoteURL: remote}
+	if err := Proxy.Clone(r, remote, "v1.0.0"); err == nil || !strings.Contains(err.Error(), "while offline") {
+		t.Errorf("got %v from downloading, want an error about being offline", err)
+	}
+	if n := atomic.LoadInt32(requests); n != 0 {
+		t.Errorf("got %d requests while offline, want none", n)
+	}
+}
+
+func TestOfflineFetch(t *testing.T) {
+	origin, r := newGitOrigin(t)
+	before := runGit(t, r.Root(), "rev-parse", "origin/main")
+	commitFile(t, origin, "b.go", "package dep\n\nconst B = 1\n")
+	useOffline(t)
+	if err := r.Fetch(); err != nil {
+		t.Fatal(err)
+	}
+	if after := runGit(t, r.Root(), "rev-parse", "origin/main"); after != before {
+		t.Errorf("origin/main moved from %s to %s while offline", before, after)
+	}
+	state := r.State()
+	if !state.Exists || state.Commit != before || state.Behind != 0 || !state.HasBranch("main") {
+		t.Errorf("got state %+v while offline, want the last fetched state at %s", state, before)
+	}
+}
+
+func TestOfflineProxyCheckout(t *testing.T) {
+	proxy := newStaticProxy(t)
+	useTempGoPath(t)
+	r := &Repo{ImportPath: testModule, VCS: Proxy, RemoteURL: proxy}
+	if err := r.Clone("v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	useOffline(t)
+	if err := r.Checkout("v1.1.0"); err == nil || !strings.Contains(err.Error(), "while offline") {
+		t.Errorf("got %v, want an error about being offline", err)
+	}
+	if _, err := r.System().Tags(r, ""); err == nil || !strings.Contains(err.Error(), "while offline") {
+		t.Errorf("got %v from listing versions, want an error about being offline", err)
+	}
+	if state := r.State(); !state.Exists || state.Commit != testCommit {
+		t.Errorf("got state %+v while offline, want commit %s", state, testCommit)
+	}
+}
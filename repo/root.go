@@ -0,0 +1,38 @@
+package repo
+
+import (
+	"strings"
+)
+
+// RootImportPath returns the import path of the repo containing the package.
+// Packages already present in $GOPATH use the repo found on disk, otherwise
+// the layouts of well-known hosts are used to determine the root.
+func RootImportPath(pkg string) string {
+	if r, err := NewFromImportPath(pkg, false); err == nil {
+		return r.ImportPath
+	}
+	parts := strings.Split(pkg, "/")
+	for i, part := range parts {
+		for _, suffix := range []string{".git", ".hg", ".svn", ".bzr"} {
+			if strings.HasSuffix(part, suffix) {
+				return strings.Join(parts[:i+1], "/")
+			}
+		}
+	}
+	count := len(parts)
+	switch parts[0] {
+	case "github.com", "bitbucket.org", "gitlab.com", "golang.org", "launchpad.net":
+		count = 3
+	case "gopkg.in":
+		count = 3
+		if len(parts) > 1 && strings.Contains(parts[1], ".v") {
+			count = 2
+		}
+	case "google.golang.org", "cloud.google.com", "go.uber.org", "k8s.io", "sigs.k8s.io":
+		count = 2
+	}
+	if count > len(parts) {
+		count = len(parts)
+	}
+	return strings.Join(parts[:count], "/")
+}
@@ -0,0 +1,45 @@
+package repo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/richardwilkes/gopathdep/util"
+)
+
+// useTempGoPath points $GOPATH at an empty directory, returning its src
+// directory.
+func useTempGoPath(t *testing.T) string {
+	t.Helper()
+	saved := util.SrcPaths
+	src := filepath.ToSlash(filepath.Join(t.TempDir(), "src")) + "/"
+	if err := os.MkdirAll(src, 0777); err != nil {
+		t.Fatal(err)
+	}
+	util.SrcPaths = []string{src}
+	t.Cleanup(func() { util.SrcPaths = saved })
+	return src
+}
+
+func TestRootImportPath(t *testing.T) {
+	useTempGoPath(t)
+	for _, test := range []struct {
+		pkg  string
+		want string
+	}{
+		{pkg: "github.com/user/project", want: "github.com/user/project"},
+		{pkg: "github.com/user/project/sub/pkg", want: "github.com/user/project"},
+		{pkg: "github.com/user", want: "github.com/user"},
+		{pkg: "golang.org/x/net/context", want: "golang.org/x/net"},
+		{pkg: "gopkg.in/yaml.v2", want: "gopkg.in/yaml.v2"},
+		{pkg: "gopkg.in/user/pkg.v1/sub", want: "gopkg.in/user/pkg.v1"},
+		{pkg: "google.golang.org/grpc/codes", want: "google.golang.org/grpc"},
+		{pkg: "example.com/repo.git/sub", want: "example.com/repo.git"},
+		{pkg: "example.com/a/b/c", want: "example.com/a/b/c"},
+	} {
+		if got := RootImportPath(test.pkg); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.pkg, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package repo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/richardwilkes/toolbox/cmdline"
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+// configV1 holds the 1.x configuration file layout, along with the keys
+// added since, so that none are lost if they were added to a 1.x file.
+type configV1 struct {
+	Version      string
+	Dependencies []*struct {
+		Import  string
+		Commit  string
+		Tag     string
+		Branch  string
+		Version string
+		Remote  string
+		VCS     string `yaml:"vcs"`
+	}
+	Ignore   []string
+	Private  []*PrivateRule
+	Insecure []string
+	Clone    *CloneOptions
+}
+
+// SchemaMajorVersion returns the major version number of a configuration
+// file version string. An empty version is treated as version 1.
+func SchemaMajorVersion(version string) (int, error) {
+	if version == "" {
+		return 1, nil
+	}
+	major := version
+	if i := strings.IndexByte(version, '.'); i != -1 {
+		major = version[:i]
+		if _, err := strconv.Atoi(version[i+1:]); err != nil {
+			return 0, errs.New(fmt.Sprintf("invalid configuration version '%s'", version))
+		}
+	}
+	n, err := strconv.Atoi(major)
+	if err != nil || n < 1 {
+		return 0, errs.New(fmt.Sprintf("invalid configuration version '%s'", version))
+	}
+	return n, nil
+}
+
+func (cfg *Config) parse(data []byte) error {
+	var header struct {
+		Version string
+	}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return errs.Wrap(err)
+	}
+	major, err := SchemaMajorVersion(header.Version)
+	if err != nil {
+		return err
+	}
+	current, _ := SchemaMajorVersion(CurrentVersion)
+	switch {
+	case major == 1:
+		err = cfg.parseV1(data)
+	case major == current:
+		err = errs.Wrap(yaml.Unmarshal(data, cfg))
+	default:
+		return errs.New(fmt.Sprintf("%s uses configuration version %s, but this version of %s only supports up to version %s", ConfigFileName, header.Version, cmdline.AppCmdName, CurrentVersion))
+	}
+	if err == nil {
+		if cfg.Version == "" {
+			cfg.Version = "1.0"
+		}
+		cfg.assignLines(data)
+	}
+	return err
+}
+
+// assignLines records the line each dependency was read from.
+func (cfg *Config) assignLines(data []byte) {
+	if doc := newDocument(data); doc != nil {
+		if _, value := mappingValue(doc.root, "dependencies"); value != nil && value.Kind == yaml.SequenceNode {
+			for i, item := range value.Content {
+				if i < len(cfg.Dependencies) {
+					cfg.Dependencies[i].line = item.Line
+				}
+			}
+		}
+	}
+}
+
+func (cfg *Config) parseV1(data []byte) error {
+	var v1 configV1
+	if err := yaml.Unmarshal(data, &v1); err != nil {
+		return errs.NewWithCause(fmt.Sprintf("unable to parse version 1 %s", ConfigFileName), err)
+	}
+	cfg.Version = v1.Version
+	cfg.Ignore = v1.Ignore
+	cfg.Private = v1.Private
+	cfg.Insecure = v1.Insecure
+	cfg.Clone = v1.Clone
+	cfg.Dependencies = make(Dependencies, 0, len(v1.Dependencies))
+	for _, one := range v1.Dependencies {
+		cfg.Dependencies = append(cfg.Dependencies, &Dependency{
+			Import:  one.Import,
+			Commit:  one.Commit,
+			Tag:     one.Tag,
+			Branch:  one.Branch,
+			Version: one.Version,
+			Remote:  one.Remote,
+			VCS:     one.VCS,
+		})
+	}
+	return nil
+}
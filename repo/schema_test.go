@@ -0,0 +1,97 @@
+package repo
+
+import "testing"
+
+func TestSchemaMajorVersion(t *testing.T) {
+	for _, test := range []struct {
+		version string
+		want    int
+		wantErr bool
+	}{
+		{version: "", want: 1},
+		{version: "1.0", want: 1},
+		{version: "2.0", want: 2},
+		{version: "3", want: 3},
+		{version: "0.9", wantErr: true},
+		{version: "x.1", wantErr: true},
+		{version: "2.x", wantErr: true},
+	} {
+		got, err := SchemaMajorVersion(test.version)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%q: unexpected error state: %v", test.version, err)
+		} else if !test.wantErr && got != test.want {
+			t.Errorf("%q: got %d, want %d", test.version, got, test.want)
+		}
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		data    string
+		wantErr bool
+		version string
+		deps    []Dependency
+	}{
+		{
+			name:    "unversioned",
+			data:    "dependencies:\n- import: a\n  tag: v1\n",
+			version: "1.0",
+			deps:    []Dependency{{Import: "a", Tag: "v1"}},
+		},
+		{
+			name:    "v1 with version and remote",
+			data:    "version: \"1.0\"\ndependencies:\n- import: a\n  version: ^1.2\n  remote: https://example.org/a\n",
+			version: "1.0",
+			deps:    []Dependency{{Import: "a", Version: "^1.2", Remote: "https://example.org/a"}},
+		},
+		{
+			name:    "v1 with later keys",
+			data:    "version: \"1.0\"\ndependencies:\n- import: a\n  branch: default\n  vcs: hg\nignore:\n- b\n",
+			version: "1.0",
+			deps:    []Dependency{{Import: "a", Branch: "default", VCS: "hg"}},
+		},
+		{
+			name:    "current",
+			data:    "version: \"2.0\"\ndependencies:\n- import: a\n  branch: main\n- import: b\n  commit: abc\n",
+			version: "2.0",
+			deps:    []Dependency{{Import: "a", Branch: "main"}, {Import: "b", Commit: "abc"}},
+		},
+		{
+			name:    "future",
+			data:    "version: \"9.0\"\ndependencies: []\n",
+			wantErr: true,
+		},
+		{
+			name:    "malformed",
+			data:    "version: [\n",
+			wantErr: true,
+		},
+	} {
+		var cfg Config
+		err := cfg.parse([]byte(test.data))
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: unexpected error state: %v", test.name, err)
+			continue
+		}
+		if test.wantErr {
+			continue
+		}
+		if cfg.Version != test.version {
+			t.Errorf("%s: version is %s, want %s", test.name, cfg.Version, test.version)
+		}
+		if len(cfg.Dependencies) != len(test.deps) {
+			t.Errorf("%s: got %d dependencies, want %d", test.name, len(cfg.Dependencies), len(test.deps))
+			continue
+		}
+		for i, dep := range cfg.Dependencies {
+			want := test.deps[i]
+			if dep.Import != want.Import || dep.Commit != want.Commit || dep.Tag != want.Tag || dep.Branch != want.Branch || dep.Version != want.Version || dep.Remote != want.Remote || dep.VCS != want.VCS {
+				t.Errorf("%s: got %+v, want %+v", test.name, *dep, want)
+			}
+			if dep.Line() == 0 {
+				t.Errorf("%s: line of %s was not recorded", test.name, dep.Import)
+			}
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package repo
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+const stashJournalFile = "stashes.json"
+
+// StashDirEnvVar is the environment variable that, when set, specifies the
+// directory that holds the stash journal.
+const StashDirEnvVar = "GOPATHDEP_STASH_DIR"
+
+// StashEntry records local changes that were set aside so that a repo could
+// be moved to another revision.
+type StashEntry struct {
+	Import  string
+	Root    string
+	Ref     string
+	Commit  string // The commit the changes were made against.
+	Created time.Time
+}
+
+var stashJournalLock sync.Mutex
+
+// StashDir returns the directory that holds the stash journal. It is kept
+// apart from the cache, so that cleaning the cache doesn't lose track of
+// stashed changes.
+func StashDir() string {
+	if dir := os.Getenv(StashDirEnvVar); dir != "" {
+		return filepath.ToSlash(dir)
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		// Fall back to the first $GOPATH, which is also kept between runs.
+		dir = filepath.Join(filepath.SplitList(build.Default.GOPATH)[0], "pkg")
+	}
+	return filepath.ToSlash(filepath.Join(dir, "gopathdep"))
+}
+
+func stashJournalPath() string {
+	return filepath.Join(StashDir(), stashJournalFile)
+}
+
+// legacyStashJournalPath returns where the journal was kept by earlier
+// versions, which was in the cache directory.
+func legacyStashJournalPath() string {
+	return filepath.Join(CacheDir(), stashJournalFile)
+}
+
+// Stash sets aside the local changes in the repo, optionally including
+// untracked files, and records them in the journal.
+func (repo *Repo) Stash(includeUntracked bool) (*StashEntry, error) {
+	stasher, ok := repo.System().(Stasher)
+	if !ok {
+		return nil, errs.New(fmt.Sprintf("unable to stash the changes in %s, as %s doesn't support it", repo.ImportPath, repo.System().Name()))
+	}
+	commit, err := repo.Commit()
+	if err != nil {
+		return nil, err
+	}
+	entry := &StashEntry{Import: repo.ImportPath, Root: repo.Root(), Commit: commit, Created: time.Now()}
+	if entry.Ref, err = stasher.Stash(repo, includeUntracked, fmt.Sprintf("gopathdep: changes made against %s", commit)); err != nil {
+		return nil, err
+	}
+	stashJournalLock.Lock()
+	defer stashJournalLock.Unlock()
+	var entries []*StashEntry
+	if entries, err = readStashJournal(); err == nil {
+		err = writeStashJournal(append(entries, entry))
+	}
+	if err != nil {
+		return nil, errs.NewWithCause(fmt.Sprintf("stashed the changes in %s as %s, but was unable to record them", repo.ImportPath, entry.Ref), err)
+	}
+	return entry, nil
+}
+
+// HasChangesToStash returns true if the repo has local modifications, or, if
+// includeUntracked is set, untracked files.
+func (repo *Repo) HasChangesToStash(includeUntracked bool) (bool, error) {
+	dirty, err := repo.System().Dirty(repo)
+	if err != nil || dirty || !includeUntracked {
+		return dirty, err
+	}
+	if stasher, ok := repo.System().(Stasher); ok {
+		return stasher.Untracked(repo)
+	}
+	return false, nil
+}
+
+// StashEntries returns the entries in the journal, oldest first.
+func StashEntries() ([]*StashEntry, error) {
+	stashJournalLock.Lock()
+	defer stashJournalLock.Unlock()
+	return readStashJournal()
+}
+
+// Restore the stashed changes and remove the entry from the journal. If the
+// changes can't be restored, the entry is kept.
+func (entry *StashEntry) Restore() error {
+	r := &Repo{ImportPath: entry.Import, VCS: VCSForDir(entry.Root)}
+	if r.Root() != entry.Root {
+		return errs.New(fmt.Sprintf("%s is not within $GOPATH", entry.Root))
+	}
+	stasher, ok := r.System().(Stasher)
+	if !ok {
+		return errs.New(fmt.Sprintf("unable to restore the changes in %s, as %s doesn't support it", entry.Import, r.System().Name()))
+	}
+	if err := stasher.Unstash(r, entry.Ref); err != nil {
+		return err
+	}
+	return entry.Discard()
+}
+
+// Discard removes the entry from the journal, without restoring its changes.
+func (entry *StashEntry) Discard() error {
+	stashJournalLock.Lock()
+	defer stashJournalLock.Unlock()
+	entries, err := readStashJournal()
+	if err != nil {
+		return err
+	}
+	remaining := entries[:0]
+	for _, one := range entries {
+		if one.Root != entry.Root || one.Ref != entry.Ref {
+			remaining = append(remaining, one)
+		}
+	}
+	return writeStashJournal(remaining)
+}
+
+func readStashJournal() ([]*StashEntry, error) {
+	var entries []*StashEntry
+	path := stashJournalPath()
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		path = legacyStashJournalPath()
+		data, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errs.Wrap(err)
+	}
+	if err = json.Unmarshal(data, &entries); err != nil {
+		return nil, errs.NewWithCause(fmt.Sprintf("unable to read %s", path), err)
+	}
+	return entries, nil
+}
+
+func writeStashJournal(entries []*StashEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return errs.Wrap(err)
+	}
+	if err = os.MkdirAll(StashDir(), 0777); err != nil {
+		return errs.Wrap(err)
+	}
+	path := stashJournalPath()
+	tmp := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+	if err = ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return errs.Wrap(err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return errs.Wrap(err)
+	}
+	// The entries now live in the journal, so the one left in the cache
+	// directory by an earlier version is no longer needed.
+	if err = os.Remove(legacyStashJournalPath()); err != nil && !os.IsNotExist(err) {
+		return errs.Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// useTempStashDir keeps the stash journal in a temporary directory for the
+// duration of the test.
+func useTempStashDir(t *testing.T) {
+	t.Helper()
+	t.Setenv(StashDirEnvVar, t.TempDir())
+}
+
+func TestStashAndRestore(t *testing.T) {
+	useTempStashDir(t)
+	_, r := newGitOrigin(t)
+	path := filepath.Join(r.Root(), "a.go")
+	writeTestFile(t, path, "package dep\n\n// local change\n")
+	entry, err := r.Stash(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content := readTestFile(t, path); content != "package dep\n" {
+		t.Errorf("got %q after stashing, want the committed content", content)
+	}
+	var entries []*StashEntry
+	if entries, err = StashEntries(); err != nil || len(entries) != 1 || entries[0].Ref != entry.Ref || entries[0].Root != r.Root() {
+		t.Fatalf("got journal %v (%v), want the new entry", entries, err)
+	}
+	if err = entries[0].Restore(); err != nil {
+		t.Fatal(err)
+	}
+	if content := readTestFile(t, path); content != "package dep\n\n// local change\n" {
+		t.Errorf("got %q after restoring, want the local change", content)
+	}
+	if entries, err = StashEntries(); err != nil || len(entries) != 0 {
+		t.Errorf("got journal %v (%v) after restoring, want it empty", entries, err)
+	}
+}
+
+func TestStashUntracked(t *testing.T) {
+	useTempStashDir(t)
+	_, r := newGitOrigin(t)
+	path := filepath.Join(r.Root(), "new.go")
+	writeTestFile(t, path, "package dep\n")
+	for _, test := range []struct {
+		includeUntracked bool
+		want             bool
+	}{
+		{includeUntracked: false, want: false},
+		{includeUntracked: true, want: true},
+	} {
+		if changed, err := r.HasChangesToStash(test.includeUntracked); err != nil || changed != test.want {
+			t.Errorf("includeUntracked=%v: got %v (%v), want %v", test.includeUntracked, changed, err, test.want)
+		}
+	}
+	entry, err := r.Stash(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("untracked file still exists after stashing: %v", err)
+	}
+	if err = entry.Restore(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(path); err != nil {
+		t.Errorf("untracked file was not restored: %v", err)
+	}
+}
+
+func TestStashNothing(t *testing.T) {
+	useTempStashDir(t)
+	_, r := newGitOrigin(t)
+	if _, err := r.Stash(false); err == nil {
+		t.Error("expected an error when there is nothing to stash")
+	}
+	if entries, err := StashEntries(); err != nil || len(entries) != 0 {
+		t.Errorf("got journal %v (%v), want it empty", entries, err)
+	}
+}
+
+func TestStashJournalDiscard(t *testing.T) {
+	useTempStashDir(t)
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	entries := []*StashEntry{
+		{Import: "example.com/a", Root: "/src/example.com/a", Ref: "1111", Commit: "aaaa", Created: created},
+		{Import: "example.com/a", Root: "/src/example.com/a", Ref: "2222", Commit: "aaaa", Created: created},
+		{Import: "example.com/b", Root: "/src/example.com/b", Ref: "1111", Commit: "bbbb", Created: created},
+	}
+	if err := writeStashJournal(entries); err != nil {
+		t.Fatal(err)
+	}
+	for i, test := range []struct {
+		discard *StashEntry
+		want    []string
+	}{
+		{discard: entries[1], want: []string{"example.com/a 1111", "example.com/b 1111"}},
+		{discard: &StashEntry{Root: "/src/example.com/c", Ref: "1111"}, want: []string{"example.com/a 1111", "example.com/b 1111"}},
+		{discard: entries[2], want: []string{"example.com/a 1111"}},
+		{discard: entries[0], want: nil},
+	} {
+		if err := test.discard.Discard(); err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		remaining, err := StashEntries()
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		var got []string
+		for _, one := range remaining {
+			got = append(got, one.Import+" "+one.Ref)
+			if !one.Created.Equal(created) {
+				t.Errorf("%d: got creation time %v, want %v", i, one.Created, created)
+			}
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("%d: got %v, want %v", i, got, test.want)
+			continue
+		}
+		for j := range got {
+			if got[j] != test.want[j] {
+				t.Errorf("%d: got %v, want %v", i, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestStashJournalOutsideCache(t *testing.T) {
+	useTempStashDir(t)
+	t.Setenv(CacheEnvVar, t.TempDir())
+	entry := &StashEntry{Import: "example.com/a", Root: "/src/example.com/a", Ref: "1111", Commit: "aaaa"}
+	writeTestFile(t, legacyStashJournalPath(), `[{"Import":"example.com/a","Root":"/src/example.com/a","Ref":"1111","Commit":"aaaa"}]`)
+	entries, err := StashEntries()
+	if err != nil || len(entries) != 1 || *entries[0] != *entry {
+		t.Fatalf("got journal %v (%v), want the entry from the cache directory", entries, err)
+	}
+	if err = writeStashJournal(entries); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(legacyStashJournalPath()); !os.IsNotExist(err) {
+		t.Errorf("the journal in the cache directory was not removed: %v", err)
+	}
+	// Cleaning the cache must not lose track of the stashed changes
+	if err = os.RemoveAll(CacheDir()); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err = StashEntries(); err != nil || len(entries) != 1 || *entries[0] != *entry {
+		t.Errorf("got journal %v (%v) after cleaning the cache, want the entry", entries, err)
+	}
+}
@@ -6,6 +6,10 @@ type State struct {
 	Branches []string
 	Tags     []string
 	Commit   string
+	Hash     string // Checksum of the files, for a VCS without history.
+	Origin   string
+	Ahead    int // Commits on the current branch that aren't upstream.
+	Behind   int // Commits upstream that aren't on the current branch.
 	Dirty    bool
 	Exists   bool
 }
@@ -29,3 +33,14 @@ func (state *State) HasTag(tag string) bool {
 	}
 	return false
 }
+
+// HasMatchingTag returns true if the repo has a tag that satisfies the
+// version constraint.
+func (state *State) HasMatchingTag(constraint *Constraint) bool {
+	for _, one := range state.Tags {
+		if constraint.Matches(one) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package repo
+
+import (
+	"os/exec"
+	"path/filepath"
+
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+// Subversion provides support for Subversion repos. Subversion has no notion
+// of tags or branches apart from paths within the repo, so dependencies kept
+// in Subversion must be pinned by revision number.
+var Subversion VCS = &svnVCS{}
+
+type svnVCS struct {
+}
+
+func (s *svnVCS) Name() string {
+	return "svn"
+}
+
+func (s *svnVCS) Dir() string {
+	return ".svn"
+}
+
+func (s *svnVCS) Clone(repo *Repo, remote, branchOrTag string) error {
+	if branchOrTag != "" {
+		return errs.New("Subversion repos can only be pinned by revision number")
+	}
+	root := repo.Root()
+	command := exec.Command(s.Name(), "checkout", "--quiet", remote, filepath.Base(root))
+	command.Dir = filepath.Dir(root)
+	_, err := runWithOutput(command)
+	return err
+}
+
+// Fetch does nothing, as a Subversion working copy has no local history to
+// update.
+func (s *svnVCS) Fetch(repo *Repo) error {
+	return nil
+}
+
+func (s *svnVCS) Checkout(repo *Repo, revision string) error {
+	_, err := repo.Exec("update", "--quiet", "--revision", revision)
+	return err
+}
+
+func (s *svnVCS) Pull(repo *Repo) error {
+	_, err := repo.Exec("update", "--quiet")
+	return err
+}
+
+func (s *svnVCS) Revision(repo *Repo) (string, error) {
+	return repo.Exec("info", "--show-item", "revision")
+}
+
+func (s *svnVCS) Tags(repo *Repo, revision string) ([]string, error) {
+	return nil, nil
+}
+
+func (s *svnVCS) Branches(repo *Repo, revision string) ([]string, error) {
+	return nil, nil
+}
+
+func (s *svnVCS) Dirty(repo *Repo) (bool, error) {
+	result, err := repo.Exec("status", "--quiet")
+	if err != nil {
+		return true, err
+	}
+	return result != "", nil
+}
+
+func (s *svnVCS) Origin(repo *Repo) (string, error) {
+	return repo.Exec("info", "--show-item", "url")
+}
+
+func (s *svnVCS) DefaultBranch(repo *Repo) (string, error) {
+	return "", nil
+}
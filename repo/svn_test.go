@@ -0,0 +1,57 @@
+package repo
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSubversion(t *testing.T) {
+	requireTool(t, "svn")
+	requireTool(t, "svnadmin")
+	useTempGoPath(t)
+	dir := t.TempDir()
+	server := filepath.Join(dir, "server")
+	runTool(t, dir, "svnadmin", "create", server)
+	remote := "file://" + filepath.ToSlash(server)
+	work := filepath.Join(dir, "work")
+	runTool(t, dir, "svn", "checkout", "--quiet", remote, work)
+	writeTestFile(t, filepath.Join(work, "a.go"), "package dep\n")
+	runTool(t, work, "svn", "add", "--quiet", "a.go")
+	runTool(t, work, "svn", "commit", "--quiet", "-m", "Add a.go")
+	writeTestFile(t, filepath.Join(work, "b.go"), "package dep\n\nconst B = 1\n")
+	runTool(t, work, "svn", "add", "--quiet", "b.go")
+	runTool(t, work, "svn", "commit", "--quiet", "-m", "Add b.go")
+
+	r := &Repo{ImportPath: "example.com/dep", VCS: Subversion, RemoteURL: remote}
+	if err := r.Clone("trunk"); err == nil {
+		t.Error("expected an error cloning a branch")
+	}
+	if err := r.Clone(""); err != nil {
+		t.Fatal(err)
+	}
+	if revision, err := r.Commit(); err != nil || revision != "2" {
+		t.Errorf("got revision %s (%v) after clone, want 2", revision, err)
+	}
+	if origin, err := r.System().Origin(r); err != nil || origin != remote {
+		t.Errorf("got origin %s (%v), want %s", origin, err, remote)
+	}
+	if tags, err := r.Tags(); err != nil || tags != nil {
+		t.Errorf("got tags %v (%v), want none", tags, err)
+	}
+	if err := r.Checkout("1"); err != nil {
+		t.Fatal(err)
+	}
+	if revision, err := r.Commit(); err != nil || revision != "1" {
+		t.Errorf("got revision %s (%v) after checkout, want 1", revision, err)
+	}
+	if dirty, err := r.System().Dirty(r); err != nil || dirty {
+		t.Errorf("got dirty %v (%v), want clean", dirty, err)
+	}
+	writeTestFile(t, filepath.Join(r.Root(), "a.go"), "package dep\n\nconst A = 1\n")
+	if dirty, err := r.System().Dirty(r); err != nil || !dirty {
+		t.Errorf("got dirty %v (%v) after a change, want dirty", dirty, err)
+	}
+	if state := r.State(); state.Commit != "1" || !state.Dirty || state.Origin != remote {
+		t.Errorf("got state %+v", state)
+	}
+}
@@ -0,0 +1,34 @@
+package repo
+
+import (
+	"fmt"
+
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+// UpdatePolicy determines how a checked out branch is brought up to date
+// with its upstream branch.
+type UpdatePolicy string
+
+// The possible UpdatePolicies
+const (
+	// FastForwardOnly only moves the branch forward, refusing to update a
+	// branch that has diverged from its upstream branch.
+	FastForwardOnly UpdatePolicy = "ff-only"
+	// ResetToRemote moves the branch to the tip of its upstream branch,
+	// abandoning any local commits.
+	ResetToRemote UpdatePolicy = "reset"
+)
+
+// ParseUpdatePolicy returns the UpdatePolicy with the name. An empty name
+// results in FastForwardOnly.
+func ParseUpdatePolicy(name string) (UpdatePolicy, error) {
+	switch UpdatePolicy(name) {
+	case "", FastForwardOnly:
+		return FastForwardOnly, nil
+	case ResetToRemote:
+		return ResetToRemote, nil
+	default:
+		return FastForwardOnly, errs.New(fmt.Sprintf("unknown update policy '%s', expected '%s' or '%s'", name, FastForwardOnly, ResetToRemote))
+	}
+}
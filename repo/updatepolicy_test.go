@@ -0,0 +1,22 @@
+package repo
+
+import "testing"
+
+func TestParseUpdatePolicy(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		want    UpdatePolicy
+		wantErr bool
+	}{
+		{name: "", want: FastForwardOnly},
+		{name: "ff-only", want: FastForwardOnly},
+		{name: "reset", want: ResetToRemote},
+		{name: "rebase", want: FastForwardOnly, wantErr: true},
+		{name: "Reset", want: FastForwardOnly, wantErr: true},
+	} {
+		got, err := ParseUpdatePolicy(test.name)
+		if got != test.want || (err != nil) != test.wantErr {
+			t.Errorf("%q: got %q (%v), want %q (error: %v)", test.name, got, err, test.want, test.wantErr)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package repo
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/richardwilkes/gopathdep/util"
+)
+
+// VCS provides access to the operations gopathdep needs from a version
+// control system. Each operation acts upon the repo's root directory.
+type VCS interface {
+	// Name returns the name used for the VCS in go-import meta tags, which
+	// is also the name of its command-line tool.
+	Name() string
+	// Dir returns the name of the metadata directory at the root of a repo.
+	Dir() string
+	// Clone the remote into the repo's root, optionally checking out a
+	// specific branch or tag.
+	Clone(repo *Repo, remote, branchOrTag string) error
+	// Fetch updates the local copy of the remote's history without changing
+	// the working copy.
+	Fetch(repo *Repo) error
+	// Checkout updates the working copy to the revision, which may be a
+	// commit, tag or branch.
+	Checkout(repo *Repo, revision string) error
+	// Pull brings the current branch up to date with the remote.
+	Pull(repo *Repo) error
+	// Revision returns the revision currently checked out.
+	Revision(repo *Repo) (string, error)
+	// Tags returns the tags that point at the revision, or all tags if the
+	// revision is empty.
+	Tags(repo *Repo, revision string) ([]string, error)
+	// Branches returns the branches whose remote tip is the revision.
+	Branches(repo *Repo, revision string) ([]string, error)
+	// Dirty returns true if the working copy has modifications to tracked
+	// files.
+	Dirty(repo *Repo) (bool, error)
+	// Origin returns the remote URL the repo was cloned from.
+	Origin(repo *Repo) (string, error)
+	// DefaultBranch returns the branch the remote checks out by default, or
+	// an empty string if the VCS has no such notion. This may be called for
+	// a repo that hasn't been cloned yet.
+	DefaultBranch(repo *Repo) (string, error)
+}
+
+// RevisionFetcher is an optional interface for a VCS whose clones may not
+// contain the full history of the remote, such as shallow git clones.
+type RevisionFetcher interface {
+	// FetchRevision retrieves the commit, tag or branch from the remote if it
+	// isn't already present, fetching further history as needed.
+	FetchRevision(repo *Repo, revision string) error
+}
+
+// UpstreamTracker is an optional interface for a VCS whose local branches
+// track branches on the remote.
+type UpstreamTracker interface {
+	// CurrentBranch returns the name of the checked out branch.
+	CurrentBranch(repo *Repo) (string, error)
+	// Divergence returns the number of commits on the current branch that
+	// are not on its upstream branch, and the number on the upstream branch
+	// that are not on the current branch. Both are zero if there is no
+	// upstream branch.
+	Divergence(repo *Repo) (ahead, behind int, err error)
+}
+
+// RevisionResolver is an optional interface for a VCS that can find the
+// commit a tag or remote branch refers to without checking it out.
+type RevisionResolver interface {
+	// ResolveRevision returns the commit for the tag, branch or commit.
+	ResolveRevision(repo *Repo, revision string) (string, error)
+}
+
+// BranchUpdater is an optional interface for a VCS that can bring a branch up
+// to date with its upstream branch according to an UpdatePolicy, rather than
+// with a plain pull.
+type BranchUpdater interface {
+	// UpdateBranch brings the checked out branch up to date with its
+	// upstream branch, as of the last fetch.
+	UpdateBranch(repo *Repo, policy UpdatePolicy) error
+}
+
+// ContentHasher is an optional interface for a VCS that has no history to
+// detect modifications with, so that a checksum of the files is recorded in
+// the lock file instead.
+type ContentHasher interface {
+	// ContentHash returns a checksum of the files in the repo.
+	ContentHash(repo *Repo) (string, error)
+}
+
+// Stasher is an optional interface for a VCS that can set local changes
+// aside and restore them later.
+type Stasher interface {
+	// Stash sets aside the local changes, optionally including untracked
+	// files, and returns a reference that identifies them.
+	Stash(repo *Repo, includeUntracked bool, message string) (string, error)
+	// Untracked returns true if the repo has files that are neither tracked
+	// nor ignored.
+	Untracked(repo *Repo) (bool, error)
+	// Unstash restores the local changes identified by the reference.
+	Unstash(repo *Repo, ref string) error
+}
+
+// VCSList holds the supported version control systems.
+var VCSList = []VCS{Git, Mercurial, Subversion, Bazaar, Proxy}
+
+// VCSByName returns the version control system with the name, or nil.
+func VCSByName(name string) VCS {
+	for _, one := range VCSList {
+		if one.Name() == name {
+			return one
+		}
+	}
+	return nil
+}
+
+// VCSForURL returns the version control system implied by the scheme of the
+// remote URL, or nil if the scheme doesn't identify one.
+func VCSForURL(remote string) VCS {
+	switch {
+	case strings.HasPrefix(remote, "svn://"), strings.HasPrefix(remote, "svn+"):
+		return Subversion
+	case strings.HasPrefix(remote, "bzr://"), strings.HasPrefix(remote, "bzr+"), strings.HasPrefix(remote, "lp:"):
+		return Bazaar
+	default:
+		return nil
+	}
+}
+
+// VCSForDir returns the version control system used by the repo rooted at
+// the directory, or nil.
+func VCSForDir(dir string) VCS {
+	for _, one := range VCSList {
+		if util.IsDir(filepath.Join(dir, one.Dir())) {
+			return one
+		}
+	}
+	return nil
+}
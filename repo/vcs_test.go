@@ -0,0 +1,41 @@
+package repo
+
+import "testing"
+
+func TestVCSForURL(t *testing.T) {
+	for _, test := range []struct {
+		remote string
+		want   VCS
+	}{
+		{remote: "https://github.com/example/repo.git", want: nil},
+		{remote: "git@github.com:example/repo.git", want: nil},
+		{remote: "ssh://hg@example.com/repo", want: nil},
+		{remote: "svn://svn.example.com/repo/trunk", want: Subversion},
+		{remote: "svn+ssh://svn.example.com/repo/trunk", want: Subversion},
+		{remote: "bzr://bzr.example.com/repo", want: Bazaar},
+		{remote: "bzr+ssh://bzr.example.com/repo", want: Bazaar},
+		{remote: "lp:example", want: Bazaar},
+	} {
+		if got := VCSForURL(test.remote); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.remote, got, test.want)
+		}
+	}
+}
+
+func TestVCSByName(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		want VCS
+	}{
+		{name: "git", want: Git},
+		{name: "hg", want: Mercurial},
+		{name: "svn", want: Subversion},
+		{name: "bzr", want: Bazaar},
+		{name: "cvs", want: nil},
+		{name: "", want: nil},
+	} {
+		if got := VCSByName(test.name); got != test.want {
+			t.Errorf("%q: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
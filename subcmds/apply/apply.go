@@ -16,6 +16,21 @@ import (
 type Cmd struct {
 }
 
+type applier struct {
+	lock             *repo.Lock
+	options          *repo.CloneOptions
+	proxy            string
+	policy           repo.UpdatePolicy
+	stash            bool
+	includeUntracked bool
+	buffer           bytes.Buffer
+	commits          map[string]string
+	hashes           map[string]string
+	roots            map[string]string
+	mutex            sync.Mutex
+	wg               sync.WaitGroup
+}
+
 // Name returns the name of the command as it needs to be entered on the command line.
 func (cmd *Cmd) Name() string {
 	return "apply"
@@ -28,123 +43,403 @@ func (cmd *Cmd) Usage() string {
 
 // Run the command.
 func (cmd *Cmd) Run(cl *cmdline.CmdLine, args []string) error {
+	var update, stash, includeUntracked bool
+	var proxy, policyName string
+	var options repo.CloneOptions
 	cl.UsageSuffix = "[path to repo]"
+	cl.NewBoolOption(&update).SetSingle('u').SetName("update").SetUsage(fmt.Sprintf("Ignore the commits recorded in %s, resolving tags, branches and versions again and recording the results", repo.LockFileName))
+	cl.NewIntOption(&options.Depth).SetName("depth").SetArg("count").SetUsage("Create shallow clones of git repos, limited to the specified number of commits")
+	cl.NewStringOption(&options.Filter).SetName("filter").SetArg("spec").SetUsage("Create partial clones of git repos, using a filter such as blob:none")
+	cl.NewBoolOption(&options.SingleBranch).SetName("single-branch").SetUsage("Only retrieve the history of a single branch when cloning git repos")
+	cl.NewStringOption(&proxy).SetName("proxy").SetArg("url").SetUsage("Download missing dependencies from the Go module proxy at the URL, rather than cloning them. Dependencies matching a private rule or with a remote are still cloned")
+	cl.NewBoolOption(&stash).SetSingle('s').SetName("stash").SetUsage(fmt.Sprintf("Stash the local changes in modified dependencies so that they can be updated. Use '%s unstash' to restore them", cmdline.AppCmdName))
+	cl.NewBoolOption(&includeUntracked).SetName("include-untracked").SetUsage("Also stash untracked files. Implies --stash")
+	cl.NewStringOption(&policyName).SetName("update-policy").SetArg("policy").SetUsage(fmt.Sprintf("How to bring a branch up to date with its remote: '%s' refuses to update a branch that has diverged, while '%s' discards any local commits", repo.FastForwardOnly, repo.ResetToRemote))
 	remainingArgs := cl.Parse(args)
 	if len(remainingArgs) == 0 {
 		remainingArgs = []string{"."}
 	}
-	cfg, err := repo.NewConfigFromDir(remainingArgs[0])
+	policy, err := repo.ParseUpdatePolicy(policyName)
+	if err != nil {
+		return err
+	}
+	var cfg *repo.Config
+	cfg, err = repo.NewConfigFromDir(remainingArgs[0])
 	if err == nil {
+		if update {
+			cfg.Lock.Dependencies = nil
+		}
 		deps := imports.GetDepInfo(cfg)
-		buffer := &bytes.Buffer{}
-		var lock sync.Mutex
-		var wg sync.WaitGroup
+		a := &applier{
+			lock:             cfg.Lock,
+			options:          cfg.Clone.Merge(&options),
+			proxy:            proxy,
+			policy:           policy,
+			stash:            stash || includeUntracked,
+			includeUntracked: includeUntracked,
+			commits:          make(map[string]string),
+			hashes:           make(map[string]string),
+			roots:            make(map[string]string),
+		}
 		for _, dep := range deps {
-			wg.Add(1)
-			go process(dep.Dependency, dep.State, buffer, &lock, &wg)
+			a.wg.Add(1)
+			go a.process(dep.Dependency, dep.State, dep.Commit)
+		}
+		a.wg.Wait()
+		for _, one := range repo.InsecureFetches() {
+			fmt.Printf("Warning: %s was fetched without transport security\n", one)
+		}
+		if len(a.roots) > 0 {
+			err = a.renameToRoots(cfg)
+		}
+		for _, dep := range cfg.Dependencies {
+			if commit, exists := a.commits[dep.Import]; exists {
+				cfg.Lock.Set(dep, commit)
+				if hash, hasHash := a.hashes[dep.Import]; hasHash {
+					cfg.Lock.SetHash(dep, hash)
+				}
+			}
+		}
+		cfg.Lock.Retain(cfg.Dependencies)
+		if err == nil {
+			err = cfg.Lock.Save()
 		}
-		wg.Wait()
-		if buffer.Len() > 0 {
-			err = errors.New(buffer.String())
+		if err == nil && a.buffer.Len() > 0 {
+			err = errors.New(a.buffer.String())
 		}
 	}
 	return err
 }
 
-func process(dep *repo.Dependency, depState imports.DepState, buffer *bytes.Buffer, lock sync.Locker, wg *sync.WaitGroup) {
-	defer wg.Done()
+func (a *applier) process(dep *repo.Dependency, depState imports.DepState, commit string) {
+	defer a.wg.Done()
 	var r *repo.Repo
 	var err error
+	pinned := a.lock.Resolve(dep)
 	switch depState {
 	case imports.MissingOnDisk:
 		if r, err = repo.NewFromImportPath(dep.Import, true); err == nil {
+			if a.proxy != "" && dep.Remote == "" && repo.PrivateRuleFor(dep.Import) == nil {
+				r.VCS = repo.Proxy
+				r.RemoteURL = a.proxy
+			} else {
+				r.VCS = repo.VCSByName(dep.VCS)
+				r.RemoteURL = dep.Remote
+				r.Options = a.options
+			}
 			var branchOrTag string
-			if dep.Commit == "" {
-				branchOrTag = dep.Tag
-				if branchOrTag == "" {
-					branchOrTag = dep.Branch
+			if pinned.Commit == "" {
+				branchOrTag = pinned.Tag
+				if branchOrTag == "" && pinned.Version == "" {
+					branchOrTag = pinned.Branch
 				}
 			}
-			if err = r.Clone(branchOrTag); err == nil {
-				if branchOrTag == "" && dep.Commit != "" {
+			if r.VCS == repo.Proxy {
+				// Download the pinned revision directly, rather than the
+				// latest version followed by the pinned one, as a proxy that
+				// only serves static files has no latest version.
+				if pinned.Commit != "" {
+					branchOrTag = pinned.Commit
+				} else if pinned.Version != "" {
+					branchOrTag, err = resolveVersion(r, pinned)
+				}
+			}
+			if err == nil {
+				err = r.Clone(branchOrTag)
+			}
+			if err == nil {
+				if r.ImportPath != dep.Import {
+					a.mutex.Lock()
+					a.roots[dep.Import] = r.ImportPath
+					a.mutex.Unlock()
+				}
+				var target string
+				if branchOrTag == "" {
+					if pinned.Commit != "" {
+						target = pinned.Commit
+					} else if pinned.Version != "" {
+						target, err = resolveVersion(r, pinned)
+					}
+				}
+				if err == nil && target != "" {
 					var existing string
 					if state := r.State(); state != nil {
 						existing = state.Commit
 					}
-					if existing != dep.Commit {
-						err = r.Checkout(dep.Commit)
+					if existing != target {
+						err = r.Checkout(target)
 					}
 				}
 				if err == nil {
-					lock.Lock()
+					err = a.recordCommit(dep, r)
+				}
+				if err == nil {
+					if target == "" {
+						target = branchOrTag
+					}
+					if target == "" {
+						if branch, branchErr := r.DefaultBranch(); branchErr == nil {
+							target = branch
+						}
+					}
+					a.mutex.Lock()
 					fmt.Printf("Cloned %s and checked out ", dep.Import)
-					describe(dep)
-					lock.Unlock()
+					describe(dep, target)
+					a.mutex.Unlock()
 				}
 			}
 		}
 		if err != nil {
-			lock.Lock()
-			fmt.Fprintln(buffer, errs.NewfWithCause(err, "Error: Unable to checkout %s", dep.Import))
-			lock.Unlock()
+			a.mutex.Lock()
+			fmt.Fprintln(&a.buffer, errs.NewfWithCause(err, "Error: Unable to checkout %s", dep.Import))
+			a.mutex.Unlock()
 		}
 	case imports.NotNeeded:
 		if r, err = repo.NewFromImportPath(dep.Import, false); err == nil {
 			if rs := r.State(); rs != nil {
-				wg.Add(1)
-				process(dep, imports.GetDepState(dep, rs), buffer, lock, wg)
+				a.wg.Add(1)
+				a.process(dep, imports.GetDepState(pinned, rs), rs.Commit)
 			}
 		} else {
-			wg.Add(1)
-			process(dep, imports.MissingOnDisk, buffer, lock, wg)
+			a.wg.Add(1)
+			a.process(dep, imports.MissingOnDisk, "")
 		}
 	case imports.IncorrectVersion:
-		if r, err = repo.NewFromImportPath(dep.Import, false); err == nil {
-			target := dep.Commit
+		a.update(dep, pinned)
+	case imports.Dirty:
+		if a.stash {
+			a.update(dep, pinned)
+		} else {
+			a.report(dep, depState)
+		}
+	case imports.Unpushed, imports.Diverged:
+		switch {
+		case a.policy == repo.ResetToRemote:
+			a.update(dep, pinned)
+		case depState == imports.Diverged:
+			a.reportDiverged(dep)
+		default:
+			a.report(dep, depState)
+		}
+	case imports.WrongRemote:
+		a.report(dep, depState)
+	case imports.InvalidConfig:
+		if _, err = dep.Constraint(); err != nil {
+			a.mutex.Lock()
+			fmt.Fprintln(&a.buffer, errs.NewfWithCause(err, "Error: %s has an invalid version", dep.Import))
+			a.mutex.Unlock()
+		} else {
+			a.report(dep, depState)
+		}
+	case imports.Good:
+		if commit != "" {
+			a.mutex.Lock()
+			a.commits[dep.Import] = commit
+			a.mutex.Unlock()
+		}
+	}
+}
+
+// renameToRoots replaces the import paths of dependencies that turned out to
+// name a package within a repo with the root of that repo, dropping any that
+// duplicate an existing dependency, and saves the configuration.
+func (a *applier) renameToRoots(cfg *repo.Config) error {
+	existing := make(map[string]bool, len(cfg.Dependencies))
+	for _, dep := range cfg.Dependencies {
+		existing[dep.Import] = true
+	}
+	deps := make(repo.Dependencies, 0, len(cfg.Dependencies))
+	for _, dep := range cfg.Dependencies {
+		root, renamed := a.roots[dep.Import]
+		if !renamed {
+			deps = append(deps, dep)
+			continue
+		}
+		if commit, exists := a.commits[dep.Import]; exists {
+			delete(a.commits, dep.Import)
+			if _, rootExists := a.commits[root]; !rootExists {
+				a.commits[root] = commit
+			}
+		}
+		if hash, exists := a.hashes[dep.Import]; exists {
+			delete(a.hashes, dep.Import)
+			if _, rootExists := a.hashes[root]; !rootExists {
+				a.hashes[root] = hash
+			}
+		}
+		if existing[root] {
+			fmt.Printf("Removed %s from %s, as it is part of %s\n", dep.Import, repo.ConfigFileName, root)
+			continue
+		}
+		fmt.Printf("Renamed %s to %s in %s, as that is the root of its repo\n", dep.Import, root, repo.ConfigFileName)
+		existing[root] = true
+		dep.Import = root
+		deps = append(deps, dep)
+	}
+	cfg.Dependencies = deps
+	return cfg.Save()
+}
+
+// update moves the repo to the revision the dependency calls for.
+func (a *applier) update(dep, pinned *repo.Dependency) {
+	r, err := repo.NewFromImportPath(dep.Import, false)
+	if err == nil {
+		if a.stash {
+			err = a.stashChanges(r)
+		}
+		if err == nil {
+			err = r.Fetch()
+		}
+		if err == nil {
+			target := pinned.Commit
+			var isBranch bool
 			if target == "" {
-				target = dep.Tag
-				if target == "" {
-					target = dep.Branch
+				target = pinned.Tag
+				if target == "" && pinned.Version != "" {
+					target, err = resolveVersion(r, pinned)
+				}
+				if target == "" && err == nil {
+					target = pinned.Branch
 					if target == "" {
-						target = "master"
+						target, err = r.DefaultBranch()
 					}
+					isBranch = true
 				}
 			}
-			if err = r.Fetch(); err == nil {
-				if err = r.Checkout(target); err == nil {
-					if target == "master" || target == dep.Branch {
-						err = r.Pull()
-					}
-					if err == nil {
-						lock.Lock()
-						fmt.Printf("Updated %s to ", dep.Import)
-						describe(dep)
-						lock.Unlock()
+			if err == nil {
+				switch {
+				case target == "":
+					// The VCS has no default branch, as the remote names the
+					// branch, so bring the working copy up to its tip.
+					err = r.Pull()
+				case isBranch:
+					if err = r.Checkout(target); err == nil {
+						err = r.UpdateBranch(a.policy)
 					}
+				default:
+					err = r.Checkout(target)
+				}
+				if err == nil {
+					err = a.recordCommit(dep, r)
+				}
+				if err == nil {
+					a.mutex.Lock()
+					fmt.Printf("Updated %s to ", dep.Import)
+					describe(dep, target)
+					a.mutex.Unlock()
 				}
 			}
 		}
-		if err != nil {
-			lock.Lock()
-			fmt.Fprintln(buffer, errs.NewfWithCause(err, "Error: Unable to update %s", dep.Import))
-			lock.Unlock()
+	}
+	if err != nil {
+		a.mutex.Lock()
+		fmt.Fprintln(&a.buffer, errs.NewfWithCause(err, "Error: Unable to update %s", dep.Import))
+		a.mutex.Unlock()
+	}
+}
+
+// stashChanges sets aside any local changes in the repo.
+func (a *applier) stashChanges(r *repo.Repo) error {
+	changed, err := r.HasChangesToStash(a.includeUntracked)
+	if err != nil || !changed {
+		return err
+	}
+	var entry *repo.StashEntry
+	if entry, err = r.Stash(a.includeUntracked); err != nil {
+		return err
+	}
+	a.mutex.Lock()
+	fmt.Printf("Stashed the changes in %s as %s\n", r.ImportPath, entry.Ref)
+	a.mutex.Unlock()
+	return nil
+}
+
+func (a *applier) report(dep *repo.Dependency, depState imports.DepState) {
+	_, description := depState.MarkerAndDescription()
+	a.mutex.Lock()
+	fmt.Fprintf(&a.buffer, "Error: %s %s\n", dep.Import, description)
+	a.mutex.Unlock()
+}
+
+// reportDiverged reports a dependency whose branch has diverged from its
+// upstream branch, along with the number of commits on each side.
+func (a *applier) reportDiverged(dep *repo.Dependency) {
+	r, err := repo.NewFromImportPath(dep.Import, false)
+	var diverged *repo.DivergedError
+	if err == nil {
+		diverged, err = r.Divergence()
+	}
+	if err != nil || diverged == nil {
+		a.report(dep, imports.Diverged)
+		return
+	}
+	a.mutex.Lock()
+	fmt.Fprintf(&a.buffer, "Error: %v\n", diverged)
+	a.mutex.Unlock()
+}
+
+// recordCommit records the commit the repo is at, along with the checksum of
+// its files if its VCS has no history. A checksum that doesn't match the one
+// in the lock file for the same commit is an error.
+func (a *applier) recordCommit(dep *repo.Dependency, r *repo.Repo) error {
+	commit, err := r.Commit()
+	if err != nil {
+		return err
+	}
+	if commit == "" {
+		// Nothing identifies the commit, as happens with a module from a
+		// proxy that doesn't report the origin of its versions, so there is
+		// nothing to lock.
+		return nil
+	}
+	var hash string
+	if hash, err = r.ContentHash(); err != nil {
+		return err
+	}
+	if hash != "" {
+		if locked := a.lock.Find(dep); locked != nil && locked.Commit == commit && locked.Hash != "" && locked.Hash != hash {
+			return errs.New(fmt.Sprintf("checksum mismatch: the files have checksum %s, but %s records %s", hash, repo.LockFileName, locked.Hash))
 		}
-	case imports.Dirty:
-		_, description := depState.MarkerAndDescription()
-		lock.Lock()
-		fmt.Fprintf(buffer, "Error: %s %s\n", dep.Import, description)
-		lock.Unlock()
 	}
+	a.mutex.Lock()
+	a.commits[dep.Import] = commit
+	if hash != "" {
+		a.hashes[dep.Import] = hash
+	}
+	a.mutex.Unlock()
+	return nil
 }
 
-func describe(dep *repo.Dependency) {
+func resolveVersion(r *repo.Repo, dep *repo.Dependency) (string, error) {
+	tags, err := r.Tags()
+	if err != nil {
+		return "", err
+	}
+	return dep.ResolveVersion(tags)
+}
+
+// describe prints what the dependency selects, along with the revision it was
+// resolved to, if that differs. For a dependency that follows the default
+// branch, the target is that branch, or empty if there isn't one.
+func describe(dep *repo.Dependency, target string) {
+	var kind, value string
 	if dep.Commit != "" {
-		fmt.Printf("commit %s\n", dep.Commit)
+		kind, value = "commit", dep.Commit
 	} else if dep.Tag != "" {
-		fmt.Printf("tag %s\n", dep.Tag)
+		kind, value = "tag", dep.Tag
+	} else if dep.Version != "" {
+		kind, value = "version", dep.Version
 	} else if dep.Branch != "" {
-		fmt.Printf("branch %s\n", dep.Branch)
+		kind, value = "branch", dep.Branch
+	} else if target != "" {
+		kind, value = "branch", target
+	} else {
+		fmt.Println("the latest revision")
+		return
+	}
+	if target != "" && target != value {
+		fmt.Printf("%s %s (%s)\n", kind, value, target)
 	} else {
-		fmt.Println("branch master")
+		fmt.Printf("%s %s\n", kind, value)
 	}
 }
@@ -0,0 +1,282 @@
+package apply
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/richardwilkes/gopathdep/imports"
+	"github.com/richardwilkes/gopathdep/repo"
+	"github.com/richardwilkes/gopathdep/util"
+)
+
+func TestRenameToRoots(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		deps        []string
+		roots       map[string]string
+		commits     map[string]string
+		want        []string
+		wantCommits map[string]string
+	}{
+		{
+			name:        "renamed",
+			deps:        []string{"example.com/a/pkg", "example.com/b"},
+			roots:       map[string]string{"example.com/a/pkg": "example.com/a"},
+			commits:     map[string]string{"example.com/a/pkg": "1111", "example.com/b": "2222"},
+			want:        []string{"example.com/a", "example.com/b"},
+			wantCommits: map[string]string{"example.com/a": "1111", "example.com/b": "2222"},
+		},
+		{
+			name:        "duplicate",
+			deps:        []string{"example.com/a", "example.com/a/pkg"},
+			roots:       map[string]string{"example.com/a/pkg": "example.com/a"},
+			commits:     map[string]string{"example.com/a": "1111", "example.com/a/pkg": "3333"},
+			want:        []string{"example.com/a"},
+			wantCommits: map[string]string{"example.com/a": "1111"},
+		},
+		{
+			name:        "two packages of one repo",
+			deps:        []string{"example.com/a/one", "example.com/a/two"},
+			roots:       map[string]string{"example.com/a/one": "example.com/a", "example.com/a/two": "example.com/a"},
+			commits:     map[string]string{"example.com/a/one": "1111"},
+			want:        []string{"example.com/a"},
+			wantCommits: map[string]string{"example.com/a": "1111"},
+		},
+	} {
+		cfg, err := repo.NewConfigFromDirOrEmpty(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, one := range test.deps {
+			cfg.Dependencies = append(cfg.Dependencies, &repo.Dependency{Import: one, Branch: "main"})
+		}
+		a := &applier{roots: test.roots, commits: test.commits, hashes: make(map[string]string)}
+		if err = a.renameToRoots(cfg); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		var saved *repo.Config
+		if saved, err = repo.NewConfigFromDir(cfg.Dir); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		var got []string
+		for _, dep := range saved.Dependencies {
+			got = append(got, dep.Import)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+		if !reflect.DeepEqual(a.commits, test.wantCommits) {
+			t.Errorf("%s: got commits %v, want %v", test.name, a.commits, test.wantCommits)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	for _, test := range []struct {
+		dep    repo.Dependency
+		target string
+		want   string
+	}{
+		{dep: repo.Dependency{Tag: "v1.2.0"}, target: "v1.2.0", want: "tag v1.2.0\n"},
+		{dep: repo.Dependency{Version: "^1.2"}, target: "v1.4.0", want: "version ^1.2 (v1.4.0)\n"},
+		{dep: repo.Dependency{Branch: "develop"}, target: "develop", want: "branch develop\n"},
+		{dep: repo.Dependency{}, target: "main", want: "branch main\n"},
+		{dep: repo.Dependency{}, want: "the latest revision\n"},
+	} {
+		dep := test.dep
+		if got := captureStdout(t, func() { describe(&dep, test.target) }); got != test.want {
+			t.Errorf("%+v: got %q, want %q", test.dep, got, test.want)
+		}
+	}
+}
+
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := os.Stdout
+	os.Stdout = w
+	f()
+	os.Stdout = saved
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestProxyCommitPin(t *testing.T) {
+	const (
+		module = "example.com/mod"
+		commit = "0123456789abcdef0123456789abcdef01234567"
+	)
+	saved := util.SrcPaths
+	src := filepath.ToSlash(filepath.Join(t.TempDir(), "src")) + "/"
+	util.SrcPaths = []string{src}
+	t.Cleanup(func() { util.SrcPaths = saved })
+
+	// A static proxy, which has no @latest and can't answer commit queries
+	dir := t.TempDir()
+	base := filepath.Join(dir, module, "@v")
+	writeFile(t, filepath.Join(base, "list"), "v1.0.0\n")
+	writeFile(t, filepath.Join(base, "v1.0.0.info"), `{"Version":"v1.0.0","Origin":{"Hash":"`+commit+`"}}`)
+	var buffer strings.Builder
+	w := zip.NewWriter(&buffer)
+	out, err := w.Create(module + "@v1.0.0/go.mod")
+	if err == nil {
+		if _, err = out.Write([]byte("module example.com/mod\n")); err == nil {
+			err = w.Close()
+		}
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(base, "v1.0.0.zip"), buffer.String())
+	var requests []string
+	var lock sync.Mutex
+	files := http.FileServer(http.Dir(dir))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		lock.Lock()
+		requests = append(requests, req.URL.Path)
+		lock.Unlock()
+		files.ServeHTTP(w, req)
+	}))
+	defer server.Close()
+
+	a := &applier{
+		lock:    &repo.Lock{},
+		proxy:   server.URL,
+		commits: make(map[string]string),
+		hashes:  make(map[string]string),
+		roots:   make(map[string]string),
+	}
+	a.wg.Add(1)
+	captureStdout(t, func() { a.process(&repo.Dependency{Import: module, Commit: commit}, imports.MissingOnDisk, "") })
+	if a.buffer.Len() > 0 {
+		t.Fatal(a.buffer.String())
+	}
+	if a.commits[module] != commit {
+		t.Errorf("got commit %q, want %s", a.commits[module], commit)
+	}
+	if _, err = os.Stat(filepath.Join(src, module, "go.mod")); err != nil {
+		t.Errorf("the module was not unpacked: %v", err)
+	}
+	var zips int
+	for _, one := range requests {
+		if strings.HasSuffix(one, "/@latest") {
+			t.Errorf("unexpected request for %s", one)
+		}
+		if strings.HasSuffix(one, ".zip") {
+			zips++
+		}
+	}
+	if zips != 1 {
+		t.Errorf("the module was downloaded %d times, want once", zips)
+	}
+}
+
+func TestDivergedBranch(t *testing.T) {
+	const module = "example.com/dep"
+	saved := util.SrcPaths
+	src := filepath.ToSlash(filepath.Join(t.TempDir(), "src")) + "/"
+	util.SrcPaths = []string{src}
+	t.Cleanup(func() { util.SrcPaths = saved })
+	for _, name := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(name, "Test")
+	}
+	for _, name := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(name, "test@example.com")
+	}
+	t.Setenv(repo.CacheEnvVar, t.TempDir())
+
+	origin := t.TempDir()
+	runGit(t, origin, "init", "--quiet", "--initial-branch", "main")
+	commitFile(t, origin, "a.go", "package dep\n")
+	r := &repo.Repo{ImportPath: module, VCS: repo.Git, RemoteURL: origin}
+	if err := r.Clone(""); err != nil {
+		t.Fatal(err)
+	}
+	upstream := commitFile(t, origin, "b.go", "package dep\n\nconst B = 1\n")
+	local := commitFile(t, r.Root(), "c.go", "package dep\n\nconst C = 1\n")
+	dep := &repo.Dependency{Import: module, Branch: "main"}
+	state := r.State()
+	depState := imports.GetDepState(dep, state)
+	if depState != imports.Diverged {
+		t.Fatalf("got state %v for %+v, want diverged", depState, state)
+	}
+
+	a := &applier{
+		lock:    &repo.Lock{},
+		policy:  repo.FastForwardOnly,
+		commits: make(map[string]string),
+		hashes:  make(map[string]string),
+		roots:   make(map[string]string),
+	}
+	a.wg.Add(1)
+	captureStdout(t, func() { a.process(dep, depState, state.Commit) })
+	if !strings.Contains(a.buffer.String(), "has diverged") {
+		t.Errorf("got %q, want a diverged error with the %s policy", a.buffer.String(), repo.FastForwardOnly)
+	}
+	if commit, err := r.Commit(); err != nil || commit != local {
+		t.Errorf("got commit %s (%v), want the local commit %s to be kept", commit, err, local)
+	}
+
+	a.buffer.Reset()
+	a.policy = repo.ResetToRemote
+	a.wg.Add(1)
+	captureStdout(t, func() { a.process(dep, depState, state.Commit) })
+	if a.buffer.Len() > 0 {
+		t.Fatal(a.buffer.String())
+	}
+	if commit, err := r.Commit(); err != nil || commit != upstream {
+		t.Errorf("got commit %s (%v), want the upstream commit %s", commit, err, upstream)
+	}
+	if depState = imports.GetDepState(dep, r.State()); depState != imports.Good {
+		t.Errorf("got state %v after reset, want good", depState)
+	}
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func commitFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	writeFile(t, filepath.Join(dir, name), content)
+	runGit(t, dir, "add", name)
+	runGit(t, dir, "commit", "--quiet", "-m", "Change "+name)
+	return runGit(t, dir, "rev-parse", "HEAD")
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/richardwilkes/gopathdep/repo"
+	"github.com/richardwilkes/toolbox/cmdline"
+	"github.com/richardwilkes/toolbox/errs"
+	"github.com/richardwilkes/toolbox/xio/term"
+)
+
+// Cmd holds the cache command.
+type Cmd struct {
+}
+
+// Name returns the name of the command as it needs to be entered on the command line.
+func (cmd *Cmd) Name() string {
+	return "cache"
+}
+
+// Usage returns a description of what the command does.
+func (cmd *Cmd) Usage() string {
+	return "List, verify or prune the cache of git mirrors"
+}
+
+// Run the command.
+func (cmd *Cmd) Run(cl *cmdline.CmdLine, args []string) error {
+	var all bool
+	days := 90
+	cl.UsageSuffix = "<list|verify|prune>"
+	cl.NewIntOption(&days).SetSingle('d').SetName("days").SetArg("count").SetUsage("When pruning, remove mirrors that haven't been used for this many days")
+	cl.NewBoolOption(&all).SetSingle('a').SetName("all").SetUsage("When pruning, remove every mirror, along with the cached results of remote lookups")
+	remainingArgs := cl.Parse(args)
+	if len(remainingArgs) != 1 {
+		return errs.New(fmt.Sprintf("An action must be specified: %s", cl.UsageSuffix))
+	}
+	mirrors, err := repo.Mirrors()
+	if err != nil {
+		return err
+	}
+	out := term.NewANSI(os.Stdout)
+	switch remainingArgs[0] {
+	case "list":
+		fmt.Fprintf(out, "Cache directory: %s\n", repo.CacheDir())
+		for _, m := range mirrors {
+			fmt.Fprintf(out, "%s [last used %s]\n", remote(m), m.Used.Format("2006-01-02"))
+		}
+	case "verify":
+		var failed int
+		for _, m := range mirrors {
+			if verifyErr := m.Verify(); verifyErr != nil {
+				failed++
+				out.Foreground(term.Red, term.Bold)
+				fmt.Fprint(out, "M")
+				out.Reset()
+				fmt.Fprintf(out, " %s is damaged: %v\n", remote(m), verifyErr)
+			} else {
+				out.Foreground(term.Green, term.Bold)
+				fmt.Fprint(out, "✓")
+				out.Reset()
+				fmt.Fprintf(out, " %s\n", remote(m))
+			}
+		}
+		if failed > 0 {
+			plural := "s"
+			if failed == 1 {
+				plural = ""
+			}
+			err = errs.New(fmt.Sprintf("%d damaged mirror%s found; use '%s cache prune' to remove them", failed, plural, cmdline.AppCmdName))
+		}
+	case "prune":
+		if all {
+			if err = repo.ClearRemoteCache(); err != nil {
+				return err
+			}
+		}
+		cutoff := time.Now().AddDate(0, 0, -days)
+		for _, m := range mirrors {
+			var reason string
+			switch {
+			case all:
+				reason = "removed"
+			case m.Used.Before(cutoff):
+				reason = fmt.Sprintf("removed, as it hasn't been used for %d days", days)
+			case m.Verify() != nil:
+				reason = "removed, as it is damaged"
+			default:
+				continue
+			}
+			if removeErr := m.Remove(); removeErr != nil {
+				return removeErr
+			}
+			fmt.Fprintf(out, "%s %s\n", remote(m), reason)
+		}
+	default:
+		err = errs.New(fmt.Sprintf("Unknown action '%s': %s", remainingArgs[0], cl.UsageSuffix))
+	}
+	return err
+}
+
+func remote(m *repo.Mirror) string {
+	if m.Remote == "" {
+		return m.Dir
+	}
+	return m.Remote
+}
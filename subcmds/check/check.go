@@ -26,11 +26,12 @@ func (cmd *Cmd) Usage() string {
 
 // Run the command.
 func (cmd *Cmd) Run(cl *cmdline.CmdLine, args []string) error {
-	var noColor, prune, errorsOnly bool
+	var noColor, prune, errorsOnly, links bool
 	cl.UsageSuffix = "[path to repo]"
 	cl.NewBoolOption(&noColor).SetSingle('n').SetName("no-color").SetUsage("Use plain output that does not contain color and is suitable for parsing with scripts")
 	cl.NewBoolOption(&prune).SetSingle('p').SetName("prune").SetUsage("Remove imports that are no longer needed from the configuration file")
 	cl.NewBoolOption(&errorsOnly).SetSingle('e').SetName("errors-only").SetUsage("Suppress output for good imports")
+	cl.NewBoolOption(&links).SetSingle('l').SetName("links").SetUsage("Show a link for browsing the source of each import")
 	remainingArgs := cl.Parse(args)
 	if len(remainingArgs) == 0 {
 		remainingArgs = []string{"."}
@@ -41,11 +42,14 @@ func (cmd *Cmd) Run(cl *cmdline.CmdLine, args []string) error {
 		if prune {
 			cfg.Dependencies = make(repo.Dependencies, 0, len(deps))
 			for _, dep := range deps {
-				if dep.State == imports.MissingOnDisk || dep.State == imports.IncorrectVersion || dep.State == imports.Dirty || dep.State == imports.Good {
+				if dep.State == imports.MissingOnDisk || dep.State == imports.IncorrectVersion || dep.State == imports.Dirty || dep.State == imports.WrongRemote || dep.State == imports.Unpushed || dep.State == imports.Diverged || dep.State == imports.InvalidConfig || dep.State == imports.Good {
 					cfg.Dependencies = append(cfg.Dependencies, dep.Dependency)
 				}
 			}
-			err = cfg.Save()
+			if err = cfg.Save(); err == nil {
+				cfg.Lock.Retain(cfg.Dependencies)
+				err = cfg.Lock.Save()
+			}
 		}
 		if err == nil {
 			out := term.NewANSI(os.Stdout)
@@ -59,6 +63,8 @@ func (cmd *Cmd) Run(cl *cmdline.CmdLine, args []string) error {
 							rev = dep.Dependency.Commit
 						} else if dep.Dependency.Tag != "" {
 							rev = dep.Dependency.Tag
+						} else if dep.Dependency.Version != "" {
+							rev = dep.Dependency.Version
 						} else if dep.Dependency.Branch != "" {
 							rev = dep.Dependency.Branch
 						}
@@ -67,8 +73,16 @@ func (cmd *Cmd) Run(cl *cmdline.CmdLine, args []string) error {
 						rev = "?"
 						revColor = term.Red
 					}
+					var link string
+					if links {
+						link = repo.LookupRemote(dep.Import).Link()
+					}
 					if noColor {
-						fmt.Fprintf(out, "%c %s [%s]\n", marker, dep.Import, rev)
+						fmt.Fprintf(out, "%c %s [%s]", marker, dep.Import, rev)
+						if link != "" {
+							fmt.Fprintf(out, " %s", link)
+						}
+						fmt.Fprintln(out)
 					} else {
 						var color term.Color
 						if dep.State == imports.Good {
@@ -91,10 +105,16 @@ func (cmd *Cmd) Run(cl *cmdline.CmdLine, args []string) error {
 							out.Reset()
 							fmt.Fprint(out, ")")
 						}
+						if link != "" {
+							fmt.Fprintf(out, " <%s>", link)
+						}
 						fmt.Fprintln(out)
 					}
 				}
 			}
+			if repo.Offline {
+				fmt.Fprintln(out, "Offline: remotes were not fetched, so remote branch tips may be stale")
+			}
 		}
 	}
 	return err
@@ -0,0 +1,70 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/richardwilkes/gopathdep/gomod"
+	"github.com/richardwilkes/gopathdep/repo"
+	"github.com/richardwilkes/gopathdep/util"
+	"github.com/richardwilkes/toolbox/cmdline"
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+// Cmd holds the export command.
+type Cmd struct {
+}
+
+// Name returns the name of the command as it needs to be entered on the command line.
+func (cmd *Cmd) Name() string {
+	return "export"
+}
+
+// Usage returns a description of what the command does.
+func (cmd *Cmd) Usage() string {
+	return "Export the configured dependencies as go.mod requirements, leaving go.sum to 'go mod tidy'"
+}
+
+// Run the command.
+func (cmd *Cmd) Run(cl *cmdline.CmdLine, args []string) error {
+	var output string
+	cl.UsageSuffix = "gomod [path to repo]"
+	cl.NewStringOption(&output).SetSingle('o').SetName("output").SetArg("file").SetUsage("Write the result to the file rather than to stdout")
+	remainingArgs := cl.Parse(args)
+	if len(remainingArgs) == 0 || remainingArgs[0] != "gomod" {
+		return errs.New(fmt.Sprintf("The export format must be specified: %s", cl.UsageSuffix))
+	}
+	remainingArgs = remainingArgs[1:]
+	if len(remainingArgs) == 0 {
+		remainingArgs = []string{"."}
+	}
+	cfg, err := repo.NewConfigFromDir(remainingArgs[0])
+	if err != nil {
+		return err
+	}
+	var buffer, problems bytes.Buffer
+	fmt.Fprintf(&buffer, "module %s\n", util.StripPrefix(cfg.Dir, util.SrcPaths))
+	if len(cfg.Dependencies) > 0 {
+		buffer.WriteString("\nrequire (\n")
+		for _, dep := range cfg.Dependencies {
+			req, reqErr := gomod.NewRequirement(dep, cfg.Lock)
+			if reqErr != nil {
+				fmt.Fprintln(&problems, errs.NewfWithCause(reqErr, "Error: Unable to export %s", dep.Import))
+				continue
+			}
+			fmt.Fprintf(&buffer, "\t%s %s\n", req.Module, req.Version)
+		}
+		buffer.WriteString(")\n")
+	}
+	if problems.Len() > 0 {
+		return errs.New(problems.String())
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(buffer.Bytes())
+	} else {
+		err = ioutil.WriteFile(output, buffer.Bytes(), 0644)
+	}
+	return errs.Wrap(err)
+}
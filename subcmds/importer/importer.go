@@ -0,0 +1,87 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/richardwilkes/gopathdep/manifest"
+	"github.com/richardwilkes/gopathdep/repo"
+	"github.com/richardwilkes/gopathdep/util"
+	"github.com/richardwilkes/toolbox/cmdline"
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+// Cmd holds the import command.
+type Cmd struct {
+}
+
+// Name returns the name of the command as it needs to be entered on the command line.
+func (cmd *Cmd) Name() string {
+	return "import"
+}
+
+// Usage returns a description of what the command does.
+func (cmd *Cmd) Usage() string {
+	return "Import the dependencies from a dep, Glide, Godep or govendor manifest"
+}
+
+// Run the command.
+func (cmd *Cmd) Run(cl *cmdline.CmdLine, args []string) error {
+	var replace bool
+	names := make([]string, 0, len(manifest.Formats))
+	for _, one := range manifest.Formats {
+		names = append(names, one.Name)
+	}
+	cl.UsageSuffix = fmt.Sprintf("<%s> [path to repo]", strings.Join(names, "|"))
+	cl.NewBoolOption(&replace).SetSingle('r').SetName("replace").SetUsage("Replace all existing dependencies, rather than only those found in the manifest")
+	remainingArgs := cl.Parse(args)
+	if len(remainingArgs) == 0 {
+		return errs.New(fmt.Sprintf("A manifest format must be specified: %s", cl.UsageSuffix))
+	}
+	format := manifest.FormatByName(remainingArgs[0])
+	if format == nil {
+		return errs.New(fmt.Sprintf("Unknown manifest format '%s'", remainingArgs[0]))
+	}
+	remainingArgs = remainingArgs[1:]
+	if len(remainingArgs) == 0 {
+		remainingArgs = []string{"."}
+	}
+	cfg, err := repo.NewConfigFromDirOrEmpty(remainingArgs[0])
+	if err != nil {
+		return err
+	}
+	entries, err := format.Read(cfg.Dir)
+	if err != nil {
+		return err
+	}
+	newMap := make(map[string]*repo.Dependency)
+	if !replace {
+		for _, dep := range cfg.Dependencies {
+			newMap[dep.Import] = dep
+		}
+	}
+	primary := util.StripPrefix(cfg.Dir, util.SrcPaths)
+	revisions := make(map[string]string)
+	for _, e := range entries {
+		if e.Import != primary {
+			newMap[e.Import] = e.Dependency()
+			revisions[e.Import] = e.Revision
+		}
+	}
+	cfg.Dependencies = make(repo.Dependencies, 0, len(newMap))
+	for _, dep := range newMap {
+		cfg.Dependencies = append(cfg.Dependencies, dep)
+	}
+	if err = cfg.Save(); err == nil {
+		for _, dep := range cfg.Dependencies {
+			if revision := revisions[dep.Import]; revision != "" {
+				cfg.Lock.Set(dep, revision)
+			}
+		}
+		cfg.Lock.Retain(cfg.Dependencies)
+		if err = cfg.Lock.Save(); err == nil {
+			fmt.Printf("Imported %d dependencies from %s\n", len(revisions), format.File)
+		}
+	}
+	return err
+}
@@ -0,0 +1,154 @@
+package lint
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/richardwilkes/gopathdep/repo"
+	"github.com/richardwilkes/gopathdep/util"
+	"github.com/richardwilkes/toolbox/cmdline"
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+var (
+	hexRegex      = regexp.MustCompile(`^[0-9a-f]+$`)
+	revisionRegex = regexp.MustCompile(`^[1-9][0-9]{0,8}$`)
+	importRegex   = regexp.MustCompile(`^[A-Za-z0-9._~+-]+(/[A-Za-z0-9._~+-]+)*$`)
+)
+
+// Cmd holds the lint command.
+type Cmd struct {
+}
+
+type problem struct {
+	line    int
+	message string
+}
+
+// Name returns the name of the command as it needs to be entered on the command line.
+func (cmd *Cmd) Name() string {
+	return "lint"
+}
+
+// Usage returns a description of what the command does.
+func (cmd *Cmd) Usage() string {
+	return "Check the configuration file for problems"
+}
+
+// Run the command.
+func (cmd *Cmd) Run(cl *cmdline.CmdLine, args []string) error {
+	cl.UsageSuffix = "[path to repo]"
+	remainingArgs := cl.Parse(args)
+	if len(remainingArgs) == 0 {
+		remainingArgs = []string{"."}
+	}
+	cfg, err := repo.NewConfigFromDir(remainingArgs[0])
+	if err != nil {
+		return err
+	}
+	problems := findProblems(cfg)
+	for _, one := range problems {
+		fmt.Printf("%s:%d: %s\n", repo.ConfigFileName, one.line, one.message)
+	}
+	if len(problems) > 0 {
+		plural := "s"
+		if len(problems) == 1 {
+			plural = ""
+		}
+		return errs.New(fmt.Sprintf("%d problem%s found in %s", len(problems), plural, repo.ConfigFileName))
+	}
+	return nil
+}
+
+// findProblems returns the problems found in the configuration, sorted by line.
+func findProblems(cfg *repo.Config) []problem {
+	var problems []problem
+	report := func(dep *repo.Dependency, format string, args ...interface{}) {
+		problems = append(problems, problem{line: dep.Line(), message: fmt.Sprintf(format, args...)})
+	}
+	primary := util.StripPrefix(cfg.Dir, util.SrcPaths)
+	seen := make(map[string]*repo.Dependency)
+	for _, dep := range cfg.Dependencies {
+		if dep.Import == "" {
+			report(dep, "missing import path")
+			continue
+		}
+		if !importRegex.MatchString(dep.Import) || path.Clean(dep.Import) != dep.Import {
+			report(dep, "malformed import path '%s'", dep.Import)
+		}
+		if first, exists := seen[dep.Import]; exists {
+			report(dep, "duplicate import %s (first listed on line %d)", dep.Import, first.Line())
+		} else {
+			seen[dep.Import] = dep
+		}
+		if dep.Import == primary {
+			report(dep, "%s is this project's own import path", dep.Import)
+		}
+		if cfg.IsIgnored(dep.Import) {
+			report(dep, "%s matches an ignore pattern", dep.Import)
+		}
+		var selectors []string
+		if dep.Commit != "" {
+			selectors = append(selectors, "commit")
+		}
+		if dep.Tag != "" {
+			selectors = append(selectors, "tag")
+		}
+		if dep.Branch != "" {
+			selectors = append(selectors, "branch")
+		}
+		if dep.Version != "" {
+			selectors = append(selectors, "version")
+		}
+		if len(selectors) > 1 {
+			report(dep, "conflicting selectors for %s: %s", dep.Import, strings.Join(selectors, ", "))
+		}
+		if dep.Commit != "" {
+			switch {
+			case revisionRegex.MatchString(dep.Commit) && hasNumberedRevisions(dep):
+				// A Subversion or Bazaar revision number
+			case !hexRegex.MatchString(dep.Commit):
+				report(dep, "malformed commit '%s' for %s", dep.Commit, dep.Import)
+			case len(dep.Commit) < 40:
+				report(dep, "abbreviated commit '%s' for %s; use the full hash", dep.Commit, dep.Import)
+			case len(dep.Commit) != 40 && len(dep.Commit) != 64:
+				report(dep, "malformed commit '%s' for %s", dep.Commit, dep.Import)
+			}
+		}
+		if dep.VCS != "" && (repo.VCSByName(dep.VCS) == nil || repo.VCSByName(dep.VCS) == repo.Proxy) {
+			report(dep, "unknown vcs '%s' for %s", dep.VCS, dep.Import)
+		}
+		if _, constraintErr := dep.Constraint(); constraintErr != nil {
+			report(dep, "invalid version '%s' for %s", dep.Version, dep.Import)
+		}
+	}
+	for _, dep := range cfg.Dependencies {
+		for _, other := range cfg.Dependencies {
+			if other != dep && other.Import != "" && strings.HasPrefix(dep.Import, other.Import+"/") {
+				report(dep, "%s is inside the repo for %s (line %d)", dep.Import, other.Import, other.Line())
+				break
+			}
+		}
+	}
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].line < problems[j].line })
+	return problems
+}
+
+// hasNumberedRevisions returns true if the dependency is kept in a VCS that
+// identifies revisions by number, going by its vcs or remote fields, or else
+// by its existing checkout.
+func hasNumberedRevisions(dep *repo.Dependency) bool {
+	vcs := repo.VCSByName(dep.VCS)
+	if vcs == nil && dep.Remote != "" {
+		vcs = repo.VCSForURL(dep.Remote)
+	}
+	if vcs == nil {
+		if r, err := repo.NewFromImportPath(dep.Import, false); err == nil {
+			vcs = r.VCS
+		}
+	}
+	return vcs == repo.Subversion || vcs == repo.Bazaar
+}
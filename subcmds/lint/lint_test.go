@@ -0,0 +1,71 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/richardwilkes/gopathdep/repo"
+	"github.com/richardwilkes/gopathdep/util"
+)
+
+func TestFindProblems(t *testing.T) {
+	saved := util.SrcPaths
+	src := filepath.ToSlash(filepath.Join(t.TempDir(), "src")) + "/"
+	util.SrcPaths = []string{src}
+	defer func() { util.SrcPaths = saved }()
+	if err := os.MkdirAll(filepath.Join(src, "example.com", "svnrepo", ".svn"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	const commit = "0123456789abcdef0123456789abcdef01234567"
+	for _, test := range []struct {
+		dep  repo.Dependency
+		want string
+	}{
+		{dep: repo.Dependency{Import: "example.com/a", Commit: commit}},
+		{dep: repo.Dependency{Import: "example.com/a", Commit: "0123abc"}, want: "abbreviated commit"},
+		{dep: repo.Dependency{Import: "example.com/a", Commit: "xyz"}, want: "malformed commit"},
+		{dep: repo.Dependency{Import: "example.com/a", Commit: "1234"}, want: "abbreviated commit"},
+		{dep: repo.Dependency{Import: "example.com/a", Commit: "1234", VCS: "svn"}},
+		{dep: repo.Dependency{Import: "example.com/a", Commit: "1234", VCS: "bzr"}},
+		{dep: repo.Dependency{Import: "example.com/a", Commit: "1234", Remote: "svn://svn.example.com/a"}},
+		{dep: repo.Dependency{Import: "example.com/svnrepo", Commit: "1234"}},
+		{dep: repo.Dependency{Import: "example.com/a", VCS: "cvs", Tag: "v1"}, want: "unknown vcs"},
+		{dep: repo.Dependency{Import: "example.com/a", VCS: "mod", Tag: "v1"}, want: "unknown vcs"},
+		{dep: repo.Dependency{Import: "example.com/a", Tag: "v1", Branch: "main"}, want: "conflicting selectors"},
+		{dep: repo.Dependency{Import: "example.com/a", Version: "^one"}, want: "invalid version"},
+		{dep: repo.Dependency{Import: "example.com//a", Tag: "v1"}, want: "malformed import path"},
+		{dep: repo.Dependency{Tag: "v1"}, want: "missing import path"},
+	} {
+		dep := test.dep
+		problems := findProblems(&repo.Config{Dir: t.TempDir(), Dependencies: repo.Dependencies{&dep}})
+		var messages []string
+		for _, one := range problems {
+			messages = append(messages, one.message)
+		}
+		got := strings.Join(messages, "; ")
+		if test.want == "" && got != "" || !strings.Contains(got, test.want) {
+			t.Errorf("%+v: got %q, want %q", test.dep, got, test.want)
+		}
+	}
+}
+
+func TestFindProblemsAcrossDependencies(t *testing.T) {
+	deps := repo.Dependencies{
+		{Import: "example.com/a", Tag: "v1"},
+		{Import: "example.com/a/sub", Tag: "v1"},
+		{Import: "example.com/a", Tag: "v2"},
+	}
+	problems := findProblems(&repo.Config{Dir: t.TempDir(), Dependencies: deps})
+	var messages []string
+	for _, one := range problems {
+		messages = append(messages, one.message)
+	}
+	got := strings.Join(messages, "; ")
+	for _, want := range []string{"duplicate import example.com/a", "example.com/a/sub is inside the repo for example.com/a"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("got %q, want it to mention %q", got, want)
+		}
+	}
+}
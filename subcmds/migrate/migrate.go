@@ -0,0 +1,43 @@
+package migrate
+
+import (
+	"fmt"
+
+	"github.com/richardwilkes/gopathdep/repo"
+	"github.com/richardwilkes/toolbox/cmdline"
+)
+
+// Cmd holds the migrate command.
+type Cmd struct {
+}
+
+// Name returns the name of the command as it needs to be entered on the command line.
+func (cmd *Cmd) Name() string {
+	return "migrate"
+}
+
+// Usage returns a description of what the command does.
+func (cmd *Cmd) Usage() string {
+	return fmt.Sprintf("Rewrite the configuration file using the current schema (version %s)", repo.CurrentVersion)
+}
+
+// Run the command.
+func (cmd *Cmd) Run(cl *cmdline.CmdLine, args []string) error {
+	cl.UsageSuffix = "[path to repo]"
+	remainingArgs := cl.Parse(args)
+	if len(remainingArgs) == 0 {
+		remainingArgs = []string{"."}
+	}
+	cfg, err := repo.NewConfigFromDir(remainingArgs[0])
+	if err == nil {
+		if cfg.Version == repo.CurrentVersion {
+			fmt.Printf("%s is already at version %s\n", repo.ConfigFileName, repo.CurrentVersion)
+		} else {
+			from := cfg.Version
+			if err = cfg.Save(); err == nil {
+				fmt.Printf("Migrated %s from version %s to version %s\n", repo.ConfigFileName, from, repo.CurrentVersion)
+			}
+		}
+	}
+	return err
+}
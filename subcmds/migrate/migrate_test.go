@@ -0,0 +1,82 @@
+package migrate
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/richardwilkes/gopathdep/repo"
+	"github.com/richardwilkes/toolbox/cmdline"
+)
+
+func TestMigrateV1(t *testing.T) {
+	dir := t.TempDir()
+	v1 := `version: "1.0"
+dependencies:
+- import: example.com/constrained
+  version: ^1.2
+- import: example.com/forked
+  branch: fixes
+  remote: https://example.org/fork/forked.git
+- import: example.com/pinned
+  commit: 0123456789abcdef0123456789abcdef01234567
+- import: example.com/hg
+  branch: default
+  vcs: hg
+ignore:
+- example.com/ignored
+private:
+- pattern: git.corp
+  remote: git@git.corp:{path}.git
+insecure:
+- insecure.example.com
+clone:
+  depth: 1
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, repo.ConfigFileName), []byte(v1), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := &Cmd{}
+	if err := cmd.Run(cmdline.New(true), []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := repo.NewConfigFromDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Version != repo.CurrentVersion {
+		t.Errorf("version is %s, want %s", cfg.Version, repo.CurrentVersion)
+	}
+	if !reflect.DeepEqual(cfg.Ignore, []string{"example.com/ignored"}) {
+		t.Errorf("got ignore %v", cfg.Ignore)
+	}
+	if len(cfg.Private) != 1 || *cfg.Private[0] != (repo.PrivateRule{Pattern: "git.corp", Remote: "git@git.corp:{path}.git"}) {
+		t.Errorf("got private %v", cfg.Private)
+	}
+	if !reflect.DeepEqual(cfg.Insecure, []string{"insecure.example.com"}) {
+		t.Errorf("got insecure %v", cfg.Insecure)
+	}
+	if cfg.Clone == nil || *cfg.Clone != (repo.CloneOptions{Depth: 1}) {
+		t.Errorf("got clone options %+v", cfg.Clone)
+	}
+	want := map[string]repo.Dependency{
+		"example.com/constrained": {Import: "example.com/constrained", Version: "^1.2"},
+		"example.com/forked":      {Import: "example.com/forked", Branch: "fixes", Remote: "https://example.org/fork/forked.git"},
+		"example.com/pinned":      {Import: "example.com/pinned", Commit: "0123456789abcdef0123456789abcdef01234567"},
+		"example.com/hg":          {Import: "example.com/hg", Branch: "default", VCS: "hg"},
+	}
+	if len(cfg.Dependencies) != len(want) {
+		t.Fatalf("got %d dependencies, want %d", len(cfg.Dependencies), len(want))
+	}
+	for _, dep := range cfg.Dependencies {
+		w, exists := want[dep.Import]
+		if !exists {
+			t.Errorf("unexpected dependency %s", dep.Import)
+			continue
+		}
+		if dep.Commit != w.Commit || dep.Tag != w.Tag || dep.Branch != w.Branch || dep.Version != w.Version || dep.Remote != w.Remote || dep.VCS != w.VCS {
+			t.Errorf("%s: got %+v, want %+v", dep.Import, *dep, w)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 
+	"github.com/richardwilkes/gopathdep/gomod"
 	"github.com/richardwilkes/gopathdep/imports"
 	"github.com/richardwilkes/gopathdep/repo"
 	"github.com/richardwilkes/gopathdep/util"
@@ -26,62 +27,106 @@ func (cmd *Cmd) Usage() string {
 
 // Run the command.
 func (cmd *Cmd) Run(cl *cmdline.CmdLine, args []string) error {
-	var notags, useMasterWhenMissing, preserve bool
+	var notags, useMasterWhenMissing, preserve, fromGoMod bool
 	cl.UsageSuffix = "[path to repo]"
 	cl.NewBoolOption(&notags).SetSingle('n').SetName("notags").SetUsage("Disables recording of tags matching the current repo state")
-	cl.NewBoolOption(&useMasterWhenMissing).SetSingle('m').SetName("master").SetUsage("Forces recording of missing repos as being tied to the master branch, rather than omitting them from the configuration")
+	cl.NewBoolOption(&useMasterWhenMissing).SetSingle('m').SetName("master").SetUsage("Forces recording of missing repos as being tied to their default branch, rather than omitting them from the configuration")
 	cl.NewBoolOption(&preserve).SetSingle('p').SetName("preserve").SetUsage("Preserve existing dependencies and only add new ones")
+	cl.NewBoolOption(&fromGoMod).SetSingle('g').SetName("from-gomod").SetUsage("Record the module versions listed in go.mod and go.sum, rather than the current repo state")
 	remainingArgs := cl.Parse(args)
 	if len(remainingArgs) == 0 {
 		remainingArgs = []string{"."}
 	}
 	var missingCount int
-	cfg := &repo.Config{Dir: util.MustGitRootOrDir(remainingArgs[0])}
+	cfg, err := repo.NewConfigFromDirOrEmpty(remainingArgs[0])
+	if err != nil {
+		return err
+	}
 	newMap := make(map[string]*repo.Dependency)
-	states := imports.GetRepoStates(remainingArgs[0])
-	for _, state := range states {
-		var tag string
-		var branch string
-		var commit string
-		if state.Exists {
-			if !notags && len(state.Tags) > 0 {
-				tag = state.Tags[0]
-			}
-			if tag == "" {
-				commit = state.Commit
-			}
-		} else if useMasterWhenMissing {
-			branch = "master"
-		} else {
-			missingCount++
+	var states []*repo.State
+	for _, state := range imports.GetRepoStates(remainingArgs[0]) {
+		if !cfg.IsIgnored(state.Import) {
+			states = append(states, state)
+		}
+	}
+	var abbreviated, unresolved []string
+	if fromGoMod {
+		roots := make([]string, 0, len(states))
+		for _, state := range states {
+			roots = append(roots, state.Import)
+		}
+		deps, abbr, err := gomod.Dependencies(cfg.Dir, roots)
+		if err != nil {
+			return err
+		}
+		for _, dep := range deps {
+			newMap[dep.Import] = dep
 		}
-		if commit != "" || tag != "" || branch != "" {
-			newMap[state.Import] = &repo.Dependency{
-				Import: state.Import,
-				Commit: commit,
-				Tag:    tag,
-				Branch: branch,
+		abbreviated = abbr
+	} else {
+		for _, state := range states {
+			var tag string
+			var branch string
+			var commit string
+			if state.Exists {
+				if !notags && len(state.Tags) > 0 {
+					tag = state.Tags[0]
+				}
+				if tag == "" {
+					commit = state.Commit
+				}
+			} else if useMasterWhenMissing {
+				r, branchErr := repo.NewFromImportPath(state.Import, true)
+				if branchErr == nil {
+					branch, branchErr = r.DefaultBranch()
+				}
+				if branchErr != nil || branch == "" {
+					unresolved = append(unresolved, state.Import)
+				}
+			} else {
+				missingCount++
+			}
+			if commit != "" || tag != "" || branch != "" {
+				newMap[state.Import] = &repo.Dependency{
+					Import: state.Import,
+					Commit: commit,
+					Tag:    tag,
+					Branch: branch,
+				}
 			}
 		}
 	}
 	if preserve {
-		if existingCfg, loadErr := repo.NewConfigFromDir(remainingArgs[0]); loadErr == nil {
-			for _, dep := range existingCfg.Dependencies {
-				newMap[dep.Import] = dep
-			}
+		for _, dep := range cfg.Dependencies {
+			newMap[dep.Import] = dep
 		}
 	}
 	cfg.Dependencies = make(repo.Dependencies, 0, len(newMap))
 	primary := util.StripPrefix(cfg.Dir, util.SrcPaths)
 	for _, dep := range newMap {
-		if dep.Import != primary {
+		if dep.Import != primary && !cfg.IsIgnored(dep.Import) {
 			cfg.Dependencies = append(cfg.Dependencies, dep)
 		}
 	}
-	err := cfg.Save()
+	err = cfg.Save()
+	if err == nil {
+		stateMap := make(map[string]*repo.State, len(states))
+		for _, state := range states {
+			stateMap[state.Import] = state
+		}
+		for _, dep := range cfg.Dependencies {
+			if state, exists := stateMap[dep.Import]; exists && state.Exists {
+				if depState := imports.GetDepState(dep, state); depState != imports.IncorrectVersion && depState != imports.InvalidConfig {
+					cfg.Lock.Set(dep, state.Commit)
+				}
+			}
+		}
+		cfg.Lock.Retain(cfg.Dependencies)
+		err = cfg.Lock.Save()
+	}
 	if err == nil {
+		buffer := bytes.Buffer{}
 		if missingCount > 0 {
-			buffer := bytes.Buffer{}
 			buffer.WriteString("The following repos cannot be found and were not added:\n")
 			for _, state := range states {
 				if !state.Exists {
@@ -90,6 +135,24 @@ func (cmd *Cmd) Run(cl *cmdline.CmdLine, args []string) error {
 					buffer.WriteString("\n")
 				}
 			}
+		}
+		if len(unresolved) > 0 {
+			buffer.WriteString("The default branch of the following repos cannot be determined, so they were not added:\n")
+			for _, one := range unresolved {
+				buffer.WriteString("    ")
+				buffer.WriteString(one)
+				buffer.WriteString("\n")
+			}
+		}
+		if len(abbreviated) > 0 {
+			buffer.WriteString("The following repos cannot be found, so their pseudo-version commits were recorded in abbreviated form:\n")
+			for _, one := range abbreviated {
+				buffer.WriteString("    ")
+				buffer.WriteString(one)
+				buffer.WriteString("\n")
+			}
+		}
+		if buffer.Len() > 0 {
 			err = errors.New(buffer.String())
 		}
 	}
@@ -24,12 +24,18 @@ func (cmd *Cmd) Name() string {
 
 // Usage returns a description of what the command does.
 func (cmd *Cmd) Usage() string {
-	return "Reset all repos on $GOPATH back to the master branch"
+	return "Reset all repos on $GOPATH back to their default branch"
 }
 
 // Run the command.
 func (cmd *Cmd) Run(cl *cmdline.CmdLine, args []string) error {
+	var policyName string
+	cl.NewStringOption(&policyName).SetName("update-policy").SetArg("policy").SetUsage(fmt.Sprintf("How to bring a branch up to date with its remote: '%s' refuses to update a branch that has diverged, while '%s' discards any local commits", repo.FastForwardOnly, repo.ResetToRemote))
 	cl.Parse(args)
+	policy, err := repo.ParseUpdatePolicy(policyName)
+	if err != nil {
+		return err
+	}
 	roots := make(map[string]bool)
 	for _, srcRoot := range util.SrcPaths {
 		if filepath.Walk(srcRoot, func(path string, info os.FileInfo, walkerErr error) error {
@@ -38,7 +44,7 @@ func (cmd *Cmd) Run(cl *cmdline.CmdLine, args []string) error {
 			}
 			if info.IsDir() {
 				name := info.Name()
-				if name == ".git" {
+				if util.IsVCSDir(name) {
 					roots[filepath.ToSlash(filepath.Dir(path))] = true
 					return filepath.SkipDir
 				}
@@ -73,14 +79,18 @@ func (cmd *Cmd) Run(cl *cmdline.CmdLine, args []string) error {
 						markerColor = term.Red
 						marker = 'M'
 						description = "is modified and will not be updated"
-					} else if !state.HasBranch("master") {
-						err = r.Checkout("master")
-						if err == nil {
-							if err = r.Pull(); err == nil {
-								markerColor = term.Green
-								marker = '✓'
-								description = "has been updated to"
-								revision = "master"
+					} else {
+						// An empty default branch means the VCS has no
+						// notion of one, as the remote names the branch.
+						var branch string
+						if branch, err = r.DefaultBranch(); err == nil && branch != "" && !state.HasBranch(branch) {
+							if err = r.Checkout(branch); err == nil {
+								if err = r.UpdateBranch(policy); err == nil {
+									markerColor = term.Green
+									marker = '✓'
+									description = "has been updated to"
+									revision = branch
+								}
 							}
 						}
 					}
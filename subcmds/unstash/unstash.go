@@ -0,0 +1,80 @@
+package unstash
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/richardwilkes/gopathdep/repo"
+	"github.com/richardwilkes/toolbox/cmdline"
+	"github.com/richardwilkes/toolbox/errs"
+	"github.com/richardwilkes/toolbox/xio/term"
+)
+
+// Cmd holds the unstash command.
+type Cmd struct {
+}
+
+// Name returns the name of the command as it needs to be entered on the command line.
+func (cmd *Cmd) Name() string {
+	return "unstash"
+}
+
+// Usage returns a description of what the command does.
+func (cmd *Cmd) Usage() string {
+	return "Restore the local changes stashed by 'apply --stash'"
+}
+
+// Run the command.
+func (cmd *Cmd) Run(cl *cmdline.CmdLine, args []string) error {
+	var list, drop bool
+	cl.UsageSuffix = "[import path...]"
+	cl.NewBoolOption(&list).SetSingle('l').SetName("list").SetUsage("List the stashed changes rather than restoring them")
+	cl.NewBoolOption(&drop).SetSingle('d').SetName("drop").SetUsage("Remove the stashed changes from the journal without restoring them. The stash entries themselves are left in the repos")
+	remainingArgs := cl.Parse(args)
+	entries, err := repo.StashEntries()
+	if err != nil {
+		return err
+	}
+	wanted := make(map[string]bool)
+	for _, one := range remainingArgs {
+		wanted[one] = true
+	}
+	out := term.NewANSI(os.Stdout)
+	var failed int
+	// Restore the newest changes first, so that multiple stashes in the same
+	// repo come back in the reverse of the order they were made.
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if len(wanted) > 0 && !wanted[entry.Import] {
+			continue
+		}
+		if list {
+			fmt.Fprintf(out, "%s %s [made against %s on %s]\n", entry.Import, entry.Ref, entry.Commit, entry.Created.Format("2006-01-02"))
+			continue
+		}
+		var action string
+		if drop {
+			action = "dropped"
+			err = entry.Discard()
+		} else {
+			action = "restored"
+			err = entry.Restore()
+		}
+		if err != nil {
+			failed++
+			out.Foreground(term.Red, term.Bold)
+			fmt.Fprint(out, "M")
+			out.Reset()
+			fmt.Fprintf(out, " %s %s could not be %s: %v\n", entry.Import, entry.Ref, action, err)
+		} else {
+			out.Foreground(term.Green, term.Bold)
+			fmt.Fprint(out, "✓")
+			out.Reset()
+			fmt.Fprintf(out, " %s %s %s\n", entry.Import, entry.Ref, action)
+		}
+	}
+	if failed > 0 {
+		return errs.New(fmt.Sprintf("%d of the stashed changes could not be processed", failed))
+	}
+	return nil
+}
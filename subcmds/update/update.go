@@ -0,0 +1,283 @@
+package update
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/richardwilkes/gopathdep/repo"
+	"github.com/richardwilkes/toolbox/cmdline"
+	"github.com/richardwilkes/toolbox/errs"
+)
+
+// Cmd holds the update command.
+type Cmd struct {
+}
+
+type result struct {
+	dep    *repo.Dependency
+	locked string
+	before string
+	after  string
+	commit string
+	err    error
+}
+
+// Name returns the name of the command as it needs to be entered on the command line.
+func (cmd *Cmd) Name() string {
+	return "update"
+}
+
+// Usage returns a description of what the command does.
+func (cmd *Cmd) Usage() string {
+	return "Advance pinned commits and tags to the latest ones available"
+}
+
+// Run the command.
+func (cmd *Cmd) Run(cl *cmdline.CmdLine, args []string) error {
+	var dryRun bool
+	var dir string
+	cl.UsageSuffix = "[import path...]"
+	cl.NewBoolOption(&dryRun).SetSingle('n').SetName("dry-run").SetUsage("Only show what would be updated, without changing the configuration")
+	cl.NewStringOption(&dir).SetSingle('d').SetName("dir").SetArg("path").SetUsage("The path to the repo holding the configuration. Defaults to the current directory")
+	remainingArgs := cl.Parse(args)
+	if dir == "" {
+		dir = "."
+	}
+	cfg, err := repo.NewConfigFromDir(dir)
+	if err != nil {
+		return err
+	}
+	deps, err := selectDependencies(cfg.Dependencies, remainingArgs)
+	if err != nil {
+		return err
+	}
+	results := make([]*result, 0, len(deps))
+	for _, dep := range deps {
+		res := &result{dep: dep}
+		if locked := cfg.Lock.Find(dep); locked != nil {
+			res.locked = locked.Commit
+		}
+		results = append(results, res)
+	}
+	var wg sync.WaitGroup
+	for _, one := range results {
+		wg.Add(1)
+		go func(res *result) {
+			defer wg.Done()
+			res.resolve()
+		}(one)
+	}
+	wg.Wait()
+	var changed, failed int
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "DEPENDENCY\tBEFORE\tAFTER")
+	for _, res := range results {
+		after := res.after
+		switch {
+		case res.err != nil:
+			failed++
+			after = fmt.Sprintf("error: %v", res.err)
+		case res.after == res.before:
+			after = "(up to date)"
+		default:
+			changed++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", res.dep.Import, res.before, after)
+	}
+	if err = w.Flush(); err != nil {
+		return errs.Wrap(err)
+	}
+	if !dryRun && changed > 0 {
+		for _, res := range results {
+			if res.err == nil && res.after != res.before {
+				switch {
+				case res.dep.Commit != "":
+					res.dep.Commit = res.commit
+				case res.dep.Tag != "":
+					res.dep.Tag = res.after
+				}
+				cfg.Lock.Set(res.dep, res.commit)
+			}
+		}
+		cfg.Lock.Retain(cfg.Dependencies)
+		if err = cfg.Save(); err == nil {
+			err = cfg.Lock.Save()
+		}
+		if err == nil {
+			fmt.Printf("Updated %d dependencies; run '%s apply' to check them out\n", changed, cmdline.AppCmdName)
+		}
+	}
+	if err == nil && failed > 0 {
+		err = errs.New(fmt.Sprintf("%d dependencies could not be updated", failed))
+	}
+	return err
+}
+
+// selectDependencies returns the dependencies with the import paths, or all
+// of them if no import paths are given.
+func selectDependencies(deps repo.Dependencies, importPaths []string) (repo.Dependencies, error) {
+	if len(importPaths) == 0 {
+		return deps, nil
+	}
+	wanted := make(map[string]bool, len(importPaths))
+	for _, one := range importPaths {
+		wanted[one] = true
+	}
+	seen := make(map[string]bool, len(importPaths))
+	selected := make(repo.Dependencies, 0, len(importPaths))
+	for _, dep := range deps {
+		if wanted[dep.Import] {
+			seen[dep.Import] = true
+			selected = append(selected, dep)
+		}
+	}
+	var missing []string
+	for one := range wanted {
+		if !seen[one] {
+			missing = append(missing, one)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, errs.New(fmt.Sprintf("not in the configuration: %s", strings.Join(missing, ", ")))
+	}
+	return selected, nil
+}
+
+// resolve determines the newest revision for the dependency. Commits advance
+// to the tip of the repo's default branch, or to the commit of the highest
+// tag that satisfies the version constraint if there is one, and tags to the
+// highest tag that is newer and satisfies any version constraint. Branches
+// and versions keep their values, while their locked commits move forward.
+func (res *result) resolve() {
+	dep := res.dep
+	switch {
+	case dep.Commit != "":
+		res.before = dep.Commit
+	case dep.Tag != "":
+		res.before = dep.Tag
+	default:
+		res.before = describe(dep, res.locked)
+	}
+	res.after = res.before
+	var r *repo.Repo
+	if r, res.err = repo.NewFromImportPath(dep.Import, false); res.err != nil {
+		res.err = errs.NewWithCause(fmt.Sprintf("%s is missing from $GOPATH", dep.Import), res.err)
+		return
+	}
+	if res.err = r.Fetch(); res.err != nil {
+		return
+	}
+	var revision string
+	switch {
+	case dep.Commit != "" && dep.Version == "":
+		if revision, res.err = defaultBranch(r); res.err != nil {
+			return
+		}
+	case dep.Tag != "":
+		if revision, res.err = newestTag(r, dep); res.err != nil {
+			return
+		}
+	case dep.Version != "":
+		if revision, res.err = resolveVersion(r, dep); res.err != nil {
+			return
+		}
+	case dep.Branch != "":
+		revision = dep.Branch
+	default:
+		if revision, res.err = defaultBranch(r); res.err != nil {
+			return
+		}
+	}
+	if res.commit, res.err = r.ResolveRevision(revision); res.err != nil {
+		return
+	}
+	switch {
+	case dep.Commit != "":
+		if !strings.HasPrefix(res.commit, dep.Commit) {
+			res.after = res.commit
+		}
+	case dep.Tag != "":
+		res.after = revision
+	case res.commit != res.locked:
+		res.after = describe(dep, res.commit)
+	}
+}
+
+// defaultBranch returns the repo's default branch, which is an error if its
+// VCS doesn't have one.
+func defaultBranch(r *repo.Repo) (string, error) {
+	branch, err := r.DefaultBranch()
+	if err == nil && branch == "" {
+		err = errs.New(fmt.Sprintf("%s has no default branch to advance to", r.ImportPath))
+	}
+	return branch, err
+}
+
+// describe returns the dependency's branch or version, along with the
+// abbreviated commit it resolves to, if known.
+func describe(dep *repo.Dependency, commit string) string {
+	text := dep.Version
+	if text == "" {
+		text = dep.Branch
+	}
+	if text == "" {
+		text = "default branch"
+	}
+	if commit == "" {
+		return text
+	}
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+	return fmt.Sprintf("%s (%s)", text, commit)
+}
+
+// newestTag returns the highest tag at or above the dependency's tag that
+// also satisfies its version constraint, if it has one. Tags that aren't
+// semantic versions are left as they are.
+func newestTag(r *repo.Repo, dep *repo.Dependency) (string, error) {
+	if _, ok := repo.ParseSemVer(dep.Tag); !ok {
+		return dep.Tag, nil
+	}
+	floor, err := repo.ParseConstraint(">=" + dep.Tag)
+	if err != nil {
+		return "", err
+	}
+	var constraint *repo.Constraint
+	if constraint, err = dep.Constraint(); err != nil {
+		return "", err
+	}
+	var tags []string
+	if tags, err = r.Tags(); err != nil {
+		return "", err
+	}
+	return highestTag(dep.Tag, floor, constraint, tags), nil
+}
+
+// highestTag returns the highest of the tags that satisfies both the floor
+// and the constraint, which may be nil, or the current tag if none do.
+func highestTag(current string, floor, constraint *repo.Constraint, tags []string) string {
+	candidates := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if constraint == nil || constraint.Matches(tag) {
+			candidates = append(candidates, tag)
+		}
+	}
+	if tag := floor.Highest(candidates); tag != "" {
+		return tag
+	}
+	return current
+}
+
+func resolveVersion(r *repo.Repo, dep *repo.Dependency) (string, error) {
+	tags, err := r.Tags()
+	if err != nil {
+		return "", err
+	}
+	return dep.ResolveVersion(tags)
+}
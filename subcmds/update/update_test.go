@@ -0,0 +1,105 @@
+package update
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/richardwilkes/gopathdep/repo"
+)
+
+func TestSelectDependencies(t *testing.T) {
+	deps := repo.Dependencies{
+		{Import: "example.com/a"},
+		{Import: "example.com/b"},
+		{Import: "example.com/c"},
+	}
+	for _, test := range []struct {
+		name    string
+		imports []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "all", want: []string{"example.com/a", "example.com/b", "example.com/c"}},
+		{name: "first", imports: []string{"example.com/a"}, want: []string{"example.com/a"}},
+		{name: "middle", imports: []string{"example.com/b"}, want: []string{"example.com/b"}},
+		{name: "several", imports: []string{"example.com/c", "example.com/a"}, want: []string{"example.com/a", "example.com/c"}},
+		{name: "missing", imports: []string{"example.com/a", "example.com/z"}, wantErr: true},
+	} {
+		selected, err := selectDependencies(deps, test.imports)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		var got []string
+		for _, dep := range selected {
+			got = append(got, dep.Import)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestHighestTag(t *testing.T) {
+	tags := []string{"v1.1.0", "v1.2.0", "v1.3.0-beta.1", "v1.3.0", "v2.0.0", "release-7"}
+	for _, test := range []struct {
+		current    string
+		constraint string
+		want       string
+	}{
+		{current: "v1.1.0", want: "v2.0.0"},
+		{current: "v1.1.0", constraint: "^1", want: "v1.3.0"},
+		{current: "v1.1.0", constraint: "~1.1", want: "v1.1.0"},
+		{current: "v1.2.0", constraint: "<1.2", want: "v1.2.0"},
+		{current: "v2.0.0", want: "v2.0.0"},
+	} {
+		floor, err := repo.ParseConstraint(">=" + test.current)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var constraint *repo.Constraint
+		if test.constraint != "" {
+			if constraint, err = repo.ParseConstraint(test.constraint); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := highestTag(test.current, floor, constraint, tags); got != test.want {
+			t.Errorf("%s with %q: got %s, want %s", test.current, test.constraint, got, test.want)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	for _, test := range []struct {
+		dep    *repo.Dependency
+		commit string
+		want   string
+	}{
+		{dep: &repo.Dependency{Branch: "main"}, commit: "0123456789abcdef", want: "main (0123456)"},
+		{dep: &repo.Dependency{Version: "^1.2"}, want: "^1.2"},
+		{dep: &repo.Dependency{}, commit: "abc", want: "default branch (abc)"},
+	} {
+		if got := describe(test.dep, test.commit); got != test.want {
+			t.Errorf("got %q, want %q", got, test.want)
+		}
+	}
+}
+
+func TestDefaultBranch(t *testing.T) {
+	for _, vcs := range []repo.VCS{repo.Subversion, repo.Bazaar} {
+		r := &repo.Repo{ImportPath: "example.com/a", VCS: vcs}
+		if branch, err := defaultBranch(r); err == nil {
+			t.Errorf("%s: got %q, want an error", vcs.Name(), branch)
+		}
+	}
+	r := &repo.Repo{ImportPath: "example.com/a", VCS: repo.Mercurial}
+	if branch, err := defaultBranch(r); err != nil || branch != "default" {
+		t.Errorf("hg: got %q (%v), want default", branch, err)
+	}
+}
@@ -14,6 +14,11 @@ import (
 // SrcPaths holds the $GOPATH source paths.
 var SrcPaths []string
 
+// VCSDirs holds the names of the metadata directories that mark the root of
+// a repo for each supported version control system, as well as for modules
+// downloaded from a module proxy.
+var VCSDirs = []string{".git", ".hg", ".svn", ".bzr", ".gopathdep"}
+
 func init() {
 	for _, path := range filepath.SplitList(build.Default.GOPATH) {
 		SrcPaths = append(SrcPaths, filepath.ToSlash(fmt.Sprintf("%s%c", filepath.Join(path, "src"), filepath.Separator)))
@@ -31,18 +36,38 @@ func IsDir(path string) bool {
 	return err == nil && fi.IsDir()
 }
 
-// GitRoot searches for the git root directory for the path.
-func GitRoot(path string) (string, error) {
+// IsVCSDir returns true if the name is one of the VCSDirs.
+func IsVCSDir(name string) bool {
+	for _, one := range VCSDirs {
+		if one == name {
+			return true
+		}
+	}
+	return false
+}
+
+// HasVCSDir returns true if the path contains one of the VCSDirs.
+func HasVCSDir(path string) bool {
+	for _, one := range VCSDirs {
+		if IsDir(filepath.ToSlash(filepath.Join(path, one))) {
+			return true
+		}
+	}
+	return false
+}
+
+// RepoRoot searches for the repo root directory for the path.
+func RepoRoot(path string) (string, error) {
 	var err error
 	original := path
 	if path, err = filepath.Abs(path); err == nil {
 		for {
-			if IsDir(filepath.ToSlash(filepath.Join(path, ".git"))) {
+			if HasVCSDir(path) {
 				return filepath.ToSlash(path), nil
 			}
 			path = filepath.Dir(path)
 			if IsRoot(path) {
-				return "", errs.New(fmt.Sprintf("%s is not part of a git repo", original))
+				return "", errs.New(fmt.Sprintf("%s is not part of a repo", original))
 			}
 		}
 	} else {
@@ -50,17 +75,17 @@ func GitRoot(path string) (string, error) {
 	}
 }
 
-// MustGitRootOrDir returns the git root or the directory if it doesn't have one.
-func MustGitRootOrDir(path string) string {
-	gitRoot, err := GitRoot(path)
+// MustRepoRootOrDir returns the repo root or the directory if it doesn't have one.
+func MustRepoRootOrDir(path string) string {
+	repoRoot, err := RepoRoot(path)
 	if err != nil {
 		if IsDir(path) {
-			gitRoot = filepath.ToSlash(path)
-		} else if gitRoot, err = os.Getwd(); err != nil {
+			repoRoot = filepath.ToSlash(path)
+		} else if repoRoot, err = os.Getwd(); err != nil {
 			log.Fatalln(errs.NewWithCause("Unable to determine the current working directory", err))
 		}
 	}
-	return gitRoot
+	return repoRoot
 }
 
 // StripPrefix strips the first prefix that matches from the path.